@@ -3,8 +3,49 @@ package contract
 import "github.com/khafidprayoga/parking-app/internal/types"
 
 type IParkingUseCase interface {
-	OpenParkingArea(lot int) error
+	OpenParkingArea(layout types.LotLayout) error
+
+	// ResizeParkingArea replace the lot layout, slot keep its number so parked car stay in place.
+	// Removed slot that is still occupied fail with ErrSlotOccupied, unless drain is set then
+	// it take no new car and is dropped once the car leave
+	ResizeParkingArea(layout types.LotLayout, drain bool) error
+
+	// DisableSlot take the slot out of allocation for maintenance, occupied slot fail
+	// with ErrSlotOccupied unless force is set
+	DisableSlot(number int, reason string, force bool) error
+	EnableSlot(number int) error
+
+	// Reserve hold the requested or the nearest available slot until the plate arrive,
+	// the slot is released when the car does not show up within the grace period
+	Reserve(request types.ReservationDTO) (reservation types.Reservation, err error)
+	CancelReservation(policeNumber string) error
+
+	// AddMember register or renew the monthly pass of the plate, member park free while it is valid
+	AddMember(request types.MemberDTO) (member types.Member, err error)
+	RemoveMember(policeNumber string) error
+	// Members return the registered plate, sorted by plate
+	Members() (members []types.Member, err error)
+
 	EnterArea(request types.CarDTO) (areaId int, err error)
 	LeaveArea(request types.CarDTO) (exitedCar types.Car, err error)
-	Status() ([]byte, error)
+	// Refund give back part or the rest of the payment, Void cancel the whole payment
+	// that is not refunded yet, both fail with ErrPaymentSettled when nothing is left
+	Refund(request types.RefundDTO) (payment types.Payment, err error)
+	Void(request types.RefundDTO) (payment types.Payment, err error)
+	// Payments return the payment selected by the query and the ledger revenue
+	Payments(query types.PaymentQuery) (report types.PaymentReport, err error)
+
+	// FindCar return the parked car of the plate
+	FindCar(policeNumber string) (car types.Car, err error)
+	// SearchCars return the parked car selected by the filter in slot order
+	SearchCars(filter types.CarFilter) (cars []types.Car, err error)
+	// CarsByColor return the parked car of the color in slot order, compared case-insensitively
+	CarsByColor(color string) (cars []types.Car, err error)
+	// SlotDetail return the slot of the number and the car parked on it
+	SlotDetail(number int) (detail types.SlotDetail, err error)
+	Status() (status types.AppStatus, err error)
+	// History return the completed parking session selected by the query, oldest first
+	History(query types.HistoryQuery) (sessions []types.Session, err error)
+	// Report return the revenue, session and occupancy per period of the query range
+	Report(query types.ReportQuery) (report types.Report, err error)
 }
@@ -0,0 +1,51 @@
+package contract
+
+import (
+	"errors"
+
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+// Error is failure returned by IParkingUseCase implementation, every backend
+// return the same sentinel so client can branch on its code instead of the message.
+// Detail is added by wrapping, e.g. fmt.Errorf("%w: %s", contract.ErrCarNotFound, plate)
+type Error struct {
+	Code    types.ErrorCode
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var (
+	ErrInvalidRequest     = &Error{Code: types.ErrCodeInvalidRequest, Message: "invalid request"}
+	ErrInvalidDuration    = &Error{Code: types.ErrCodeInvalidDuration, Message: "parking hours must not be negative"}
+	ErrNotInitialized     = &Error{Code: types.ErrCodeNotInitialized, Message: "parking lot is not initialized"}
+	ErrAlreadyInitialized = &Error{Code: types.ErrCodeAlreadyInitialized, Message: "parking lot is already initialized"}
+	ErrLotFull            = &Error{Code: types.ErrCodeLotFull, Message: "parking lot is full"}
+	ErrAlreadyParked      = &Error{Code: types.ErrCodeAlreadyParked, Message: "car is already parked"}
+	ErrCarNotFound        = &Error{Code: types.ErrCodeNotFound, Message: "car does not exist on parking area"}
+	ErrLotNotFound        = &Error{Code: types.ErrCodeLotNotFound, Message: "parking lot does not exist"}
+	ErrSlotOccupied       = &Error{Code: types.ErrCodeSlotOccupied, Message: "slot is still occupied"}
+	ErrSlotNotFound       = &Error{Code: types.ErrCodeSlotNotFound, Message: "slot does not exist on parking area"}
+	ErrAlreadyReserved    = &Error{Code: types.ErrCodeAlreadyReserved, Message: "car already has reservation"}
+	ErrNoReservation      = &Error{Code: types.ErrCodeNoReservation, Message: "car has no reservation"}
+	ErrMemberNotFound     = &Error{Code: types.ErrCodeMemberNotFound, Message: "plate is not a member"}
+	ErrPaymentNotFound    = &Error{Code: types.ErrCodePaymentNotFound, Message: "payment does not exist"}
+	ErrPaymentSettled     = &Error{Code: types.ErrCodePaymentSettled, Message: "payment has nothing left to refund"}
+	ErrStorageUnavailable = &Error{Code: types.ErrCodeStorageUnavailable, Message: "storage is unavailable"}
+)
+
+// CodeOf return the code of the contract error wrapped in err, untyped error is internal failure
+func CodeOf(err error) types.ErrorCode {
+	if err == nil {
+		return ""
+	}
+
+	var contractErr *Error
+	if errors.As(err, &contractErr) {
+		return contractErr.Code
+	}
+	return types.ErrCodeInternal
+}
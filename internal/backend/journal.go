@@ -0,0 +1,251 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+// write-ahead log operation name
+const (
+	opOpen    = "open"
+	opResize  = "resize"
+	opEnter   = "enter"
+	opLeave   = "leave"
+	opDisable = "disable"
+	opEnable  = "enable"
+	opReserve = "reserve"
+	opCancel  = "cancel"
+
+	opMember       = "member"
+	opRemoveMember = "remove_member"
+
+	opRefund = "refund"
+	opVoid   = "void"
+)
+
+type openEntry struct {
+	Capacity int `json:"capacity"`
+
+	// Layout is empty on log written before slot was typed, it was all car slot
+	Layout types.LotLayout `json:"layout,omitempty"`
+}
+
+type resizeEntry struct {
+	Layout types.LotLayout `json:"layout"`
+	Drain  bool            `json:"drain"`
+}
+
+// slotEntry is disabled or enabled slot, disabled since the entry time
+type slotEntry struct {
+	Number int    `json:"number"`
+	Reason string `json:"reason,omitempty"`
+	Force  bool   `json:"force,omitempty"`
+}
+
+type reserveEntry struct {
+	Request types.ReservationDTO `json:"request"`
+
+	// Reserved pin the held slot and expiry, so replay does not depend on the grace config
+	Reserved types.Reservation `json:"reserved"`
+}
+
+type cancelEntry struct {
+	PoliceNumber string `json:"police_number"`
+}
+
+type memberEntry struct {
+	Request types.MemberDTO `json:"request"`
+}
+
+type adjustEntry struct {
+	Request types.RefundDTO `json:"request"`
+
+	// Entry pin the reversing ledger entry, so replay keep its id and amount
+	Entry types.LedgerEntry `json:"entry"`
+}
+
+type enterEntry struct {
+	Request types.CarDTO `json:"request"`
+}
+
+type leaveEntry struct {
+	Request types.CarDTO `json:"request"`
+
+	// Exited pin the charged cost, so replay never re-price a past transaction
+	Exited types.Car `json:"exited"`
+}
+
+// mutator is the raw state transition of a backend, shared by live call and log replay
+type mutator interface {
+	openArea(layout types.LotLayout) error
+	resize(layout types.LotLayout, drain bool, at time.Time) error
+	enter(request types.CarDTO, at time.Time) (areaId int, err error)
+	leave(request types.CarDTO, at time.Time, priced *types.Car) (exitedCar types.Car, err error)
+	disable(number int, reason string, force bool, at time.Time) error
+	enable(number int) error
+	reserve(request types.ReservationDTO, at time.Time, pinned *types.Reservation) (reservation types.Reservation, err error)
+	cancel(policeNumber string, at time.Time) error
+	addMember(request types.MemberDTO, at time.Time) (member types.Member, err error)
+	removeMember(policeNumber string) error
+	adjust(kind string, request types.RefundDTO, at time.Time, pinned *types.LedgerEntry) (entry types.LedgerEntry, err error)
+}
+
+// journal write every acknowledged mutation into the store write-ahead log
+type journal struct {
+	db    store.Store
+	seq   uint64
+	fault error
+}
+
+// ready reject mutation after the log failed to append, the in memory state
+// may be ahead of disk and only a restart bring them back in sync
+func (j *journal) ready() error {
+	if j.fault != nil {
+		return fmt.Errorf("%w, restart the server: %v", contract.ErrStorageUnavailable, j.fault)
+	}
+	return nil
+}
+
+// record append the mutation into the log, caller must hold the backend lock
+func (j *journal) record(op string, at time.Time, payload any) error {
+	if j.db == nil {
+		return nil
+	}
+
+	data, errMarshal := json.Marshal(payload)
+	if errMarshal != nil {
+		return fmt.Errorf("failed to marshal %s log entry: %v", op, errMarshal)
+	}
+
+	errAppend := j.db.Append(store.Entry{
+		Seq:  j.seq + 1,
+		Op:   op,
+		At:   at,
+		Data: data,
+	})
+	if errAppend != nil {
+		j.fault = errAppend
+		return fmt.Errorf("%w, failed to persist %s: %v", contract.ErrStorageUnavailable, op, errAppend)
+	}
+
+	j.seq++
+	return nil
+}
+
+// load return the persisted snapshot and the log entries written after it
+func (j *journal) load() (state *store.Snapshot, entries []store.Entry, err error) {
+	if j.db == nil {
+		return
+	}
+
+	state, entries, err = j.db.Load()
+	if err != nil {
+		err = fmt.Errorf("failed to restore parking state: %v", err)
+		return nil, nil, err
+	}
+
+	if state != nil {
+		j.seq = state.Seq
+	}
+	return
+}
+
+// replay re-apply the log entries on top of the restored snapshot
+func (j *journal) replay(m mutator, entries []store.Entry) (err error) {
+	for _, entry := range entries {
+		var errApply error
+
+		switch entry.Op {
+		case opOpen:
+			payload := openEntry{}
+			if errApply = json.Unmarshal(entry.Data, &payload); errApply == nil {
+				if len(payload.Layout) == 0 {
+					payload.Layout = types.UniformLayout(payload.Capacity)
+				}
+				errApply = m.openArea(payload.Layout)
+			}
+		case opResize:
+			payload := resizeEntry{}
+			if errApply = json.Unmarshal(entry.Data, &payload); errApply == nil {
+				errApply = m.resize(payload.Layout, payload.Drain, entry.At)
+			}
+		case opEnter:
+			payload := enterEntry{}
+			if errApply = json.Unmarshal(entry.Data, &payload); errApply == nil {
+				_, errApply = m.enter(payload.Request, entry.At)
+			}
+		case opLeave:
+			payload := leaveEntry{}
+			if errApply = json.Unmarshal(entry.Data, &payload); errApply == nil {
+				_, errApply = m.leave(payload.Request, entry.At, &payload.Exited)
+			}
+		case opDisable:
+			payload := slotEntry{}
+			if errApply = json.Unmarshal(entry.Data, &payload); errApply == nil {
+				errApply = m.disable(payload.Number, payload.Reason, payload.Force, entry.At)
+			}
+		case opEnable:
+			payload := slotEntry{}
+			if errApply = json.Unmarshal(entry.Data, &payload); errApply == nil {
+				errApply = m.enable(payload.Number)
+			}
+		case opReserve:
+			payload := reserveEntry{}
+			if errApply = json.Unmarshal(entry.Data, &payload); errApply == nil {
+				_, errApply = m.reserve(payload.Request, entry.At, &payload.Reserved)
+			}
+		case opCancel:
+			payload := cancelEntry{}
+			if errApply = json.Unmarshal(entry.Data, &payload); errApply == nil {
+				errApply = m.cancel(payload.PoliceNumber, entry.At)
+			}
+		case opMember:
+			payload := memberEntry{}
+			if errApply = json.Unmarshal(entry.Data, &payload); errApply == nil {
+				_, errApply = m.addMember(payload.Request, entry.At)
+			}
+		case opRemoveMember:
+			payload := cancelEntry{}
+			if errApply = json.Unmarshal(entry.Data, &payload); errApply == nil {
+				errApply = m.removeMember(payload.PoliceNumber)
+			}
+		case opRefund, opVoid:
+			payload := adjustEntry{}
+			if errApply = json.Unmarshal(entry.Data, &payload); errApply == nil {
+				_, errApply = m.adjust(payload.Entry.Kind, payload.Request, entry.At, &payload.Entry)
+			}
+		default:
+			errApply = fmt.Errorf("unknown operation %s", entry.Op)
+		}
+
+		if errApply != nil {
+			err = fmt.Errorf("failed to replay log entry %d (%s): %v", entry.Seq, entry.Op, errApply)
+			return
+		}
+
+		j.seq = entry.Seq
+	}
+	return
+}
+
+// compact save the snapshot covering every recorded entry, caller must hold the backend lock
+func (j *journal) compact(state store.Snapshot) error {
+	if j.db == nil {
+		return nil
+	}
+
+	if errReady := j.ready(); errReady != nil {
+		return errReady
+	}
+
+	state.Seq = j.seq
+	if errSave := j.db.Save(state); errSave != nil {
+		return fmt.Errorf("failed to compact parking state: %v", errSave)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+// methodOf validate the payment method of the leaving car, cash when not given
+func methodOf(request types.CarDTO) (method string, err error) {
+	method = strings.ToLower(strings.TrimSpace(request.Method))
+	if method == "" {
+		return types.PaymentCash, nil
+	}
+
+	if !types.IsPaymentMethod(method) {
+		err = fmt.Errorf("%w: unknown payment method %s, expected one of %s", contract.ErrInvalidRequest, method, strings.Join(types.PaymentMethods, ", "))
+		return
+	}
+	return method, nil
+}
+
+// priceIn put the amount into the lot currency, plain amount of request and older state is
+// priced in it while amount of another currency is refused as there is no exchange rate
+func priceIn(amount money.Money, currency string) (money.Money, error) {
+	if amount.Currency != "" && amount.Currency != currency {
+		return amount, fmt.Errorf("%w: amount %v is not in %s", contract.ErrInvalidRequest, amount, currency)
+	}
+	return amount.In(currency), nil
+}
+
+// repriced put the cost and discount of the car into the lot currency
+func repriced(car types.Car, currency string) (types.Car, error) {
+	var err error
+	if car.Cost, err = priceIn(car.Cost, currency); err != nil {
+		return car, err
+	}
+	car.Discount, err = priceIn(car.Discount, currency)
+	return car, err
+}
+
+// paymentEntry collect the cost of the exited car into its payment method account
+func paymentEntry(car types.Car, at time.Time) types.LedgerEntry {
+	return types.LedgerEntry{
+		Id:           car.PaymentId,
+		Kind:         types.EntryPayment,
+		PoliceNumber: car.PoliceNumber,
+		Debit:        car.Method,
+		Credit:       types.AccountRevenue,
+		Amount:       car.Cost,
+		At:           at,
+	}
+}
+
+// adjustment validate the refund or void of the payment and return its reversing entry,
+// pinned is the entry already in the log
+func adjustment(ledger types.Ledger, kind string, request types.RefundDTO, at time.Time, pinned *types.LedgerEntry) (entry types.LedgerEntry, err error) {
+	payment, ok := ledger.Payment(request.PaymentId)
+	if !ok {
+		err = fmt.Errorf("%w: %s", contract.ErrPaymentNotFound, request.PaymentId)
+		return
+	}
+
+	if payment.Remaining().Sign() <= 0 {
+		err = fmt.Errorf("%w: payment %s is %s", contract.ErrPaymentSettled, payment.Id, payment.Status)
+		return
+	}
+
+	amount := payment.Remaining()
+	switch kind {
+	case types.EntryVoid:
+		// void cancel the whole charge, partly refunded payment is refunded instead
+		if payment.Refunded.Sign() > 0 {
+			err = fmt.Errorf("%w: payment %s is already partially refunded", contract.ErrPaymentSettled, payment.Id)
+			return
+		}
+	case types.EntryRefund:
+		requested, errPrice := priceIn(request.Amount, amount.Currency)
+		if errPrice != nil {
+			return entry, errPrice
+		}
+		if requested.Sign() < 0 || requested.Cmp(amount) > 0 {
+			err = fmt.Errorf("%w: refund must be between 0 and %v", contract.ErrInvalidRequest, amount)
+			return
+		}
+		if requested.Sign() > 0 {
+			amount = requested
+		}
+	}
+
+	if pinned != nil {
+		entry = *pinned
+		entry.Amount, err = priceIn(entry.Amount, amount.Currency)
+		return
+	}
+
+	return types.LedgerEntry{
+		Id:           uuid.NewString(),
+		PaymentId:    payment.Id,
+		Kind:         kind,
+		PoliceNumber: payment.PoliceNumber,
+		Debit:        types.AccountRevenue,
+		Credit:       payment.Method,
+		Amount:       amount,
+		At:           at,
+		Reason:       strings.TrimSpace(request.Reason),
+		RequestId:    request.RequestId,
+	}, nil
+}
+
+// reprice put every amount of the restored state into the lot currency, state priced in
+// another currency is refused rather than mixed
+func reprice(state *store.Snapshot, currency string) (err error) {
+	if state.Currency != "" && state.Currency != currency {
+		return fmt.Errorf("failed to restore parking state: priced in %s, server currency is %s", state.Currency, currency)
+	}
+
+	for _, car := range state.CarList {
+		if car == nil {
+			continue
+		}
+		if *car, err = repriced(*car, currency); err != nil {
+			return
+		}
+	}
+
+	for i, session := range state.Sessions {
+		if state.Sessions[i].Cost, err = priceIn(session.Cost, currency); err != nil {
+			return
+		}
+		if state.Sessions[i].Discount, err = priceIn(session.Discount, currency); err != nil {
+			return
+		}
+	}
+
+	for i, entry := range state.Ledger {
+		if state.Ledger[i].Amount, err = priceIn(entry.Amount, currency); err != nil {
+			return
+		}
+	}
+
+	state.Revenue, err = priceIn(state.Revenue, currency)
+	state.Currency = currency
+	return
+}
+
+// ledgerOf restore the ledger, snapshot written before the ledger existed only has the
+// running revenue and it is carried over as opening balance
+func ledgerOf(state *store.Snapshot) types.Ledger {
+	if len(state.Ledger) > 0 || state.Revenue.IsZero() {
+		return state.Ledger
+	}
+
+	return types.Ledger{{
+		Id:     uuid.NewString(),
+		Kind:   types.EntryOpening,
+		Debit:  types.AccountOpening,
+		Credit: types.AccountRevenue,
+		Amount: state.Revenue,
+	}}
+}
@@ -0,0 +1,98 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/plate"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/tariff"
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+const (
+	maxMemberNameLength = 64
+	maxMemberMonths     = 12
+)
+
+// renewMember register the plate for the requested months, active pass is extended from
+// its current end so renewing early lose no day
+func renewMember(request types.MemberDTO, current *types.Member, at time.Time) (member types.Member, err error) {
+	if err = validPlate(request.PoliceNumber); err != nil {
+		return
+	}
+
+	if request.Months < 0 || request.Months > maxMemberMonths {
+		err = fmt.Errorf("%w: monthly pass must be 1 to %d months", contract.ErrInvalidRequest, maxMemberMonths)
+		return
+	}
+
+	name := strings.TrimSpace(request.Name)
+	if len(name) > maxMemberNameLength {
+		err = fmt.Errorf("%w: member name longer than %d character", contract.ErrInvalidRequest, maxMemberNameLength)
+		return
+	}
+
+	member = types.Member{
+		PoliceNumber: request.GetPoliceNumber(),
+		Name:         name,
+		Since:        at,
+	}
+
+	start := at
+	if current != nil {
+		member.Since = current.Since
+		if name == "" {
+			member.Name = current.Name
+		}
+		if current.Active(at) {
+			start = current.ValidUntil
+		}
+	}
+	member.ValidUntil = start.AddDate(0, request.GetMonths(), 0)
+	return member, nil
+}
+
+// sortedMembers list the member by plate
+func sortedMembers(members map[string]types.Member) []types.Member {
+	list := make([]types.Member, 0, len(members))
+	for _, member := range members {
+		list = append(list, member)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].PoliceNumber < list[j].PoliceNumber })
+	return list
+}
+
+// discountOf price the discount of the leaving car, active monthly pass park free and
+// otherwise the loyalty tier reached by its completed visits before this one apply
+func discountOf(cost money.Money, member *types.Member, loyalty tariff.Loyalty, visits int, at time.Time) (discount money.Money, reason string) {
+	discount = money.FromMinor(0, cost.Currency)
+	if cost.Sign() <= 0 {
+		return
+	}
+
+	if member != nil && member.Active(at) {
+		return cost, "monthly pass"
+	}
+
+	tier, ok := loyalty.TierOf(visits)
+	if !ok {
+		return
+	}
+
+	return cost.Percent(tier.Percent), fmt.Sprintf("loyalty %v%% after %d visit", tier.Percent, tier.Visits)
+}
+
+// txOf restore the completed visit count per plate key, snapshot written before plate was
+// normalized is keyed by the raw police number and the spelling of one plate is merged
+func txOf(state *store.Snapshot) map[string]int {
+	tx := make(map[string]int, len(state.Tx))
+	for policeNumber, visits := range state.Tx {
+		tx[plate.Key(policeNumber)] += visits
+	}
+	return tx
+}
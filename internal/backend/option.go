@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"strings"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/internal/clock"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/tariff"
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+type options struct {
+	db     store.Store
+	tariff tariff.Tariff
+	clock  clock.Clock
+
+	// currency is the code of every amount, cost and ledger alike
+	currency string
+
+	// loyalty is the discount tier by completed visits, none when empty
+	loyalty tariff.Loyalty
+
+	// grace is how long reservation is held after its start before the car count as no-show
+	grace time.Duration
+
+	// simulateHours accept the leave hours override, rejected otherwise
+	simulateHours bool
+}
+
+// Option configure optional dependency of the parking service backend
+type Option func(*options)
+
+// WithStore make the backend write through every mutation into db
+func WithStore(db store.Store) Option {
+	return func(o *options) {
+		o.db = db
+	}
+}
+
+// WithTariff price the leaving car with t instead of the default tariff
+func WithTariff(t tariff.Tariff) Option {
+	return func(o *options) {
+		o.tariff = t
+	}
+}
+
+// WithLoyalty discount the leaving car by the highest tier its completed visits reached
+func WithLoyalty(l tariff.Loyalty) Option {
+	return func(o *options) {
+		o.loyalty = l
+	}
+}
+
+// WithCurrency price every amount in currency code, e.g. IDR, empty code keep the default
+func WithCurrency(code string) Option {
+	return func(o *options) {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			o.currency = code
+		}
+	}
+}
+
+// WithClock read the current time from c instead of the wall clock
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithReservationGrace release the reserved slot when the car does not arrive within d of the reservation start
+func WithReservationGrace(d time.Duration) Option {
+	return func(o *options) {
+		o.grace = d
+	}
+}
+
+// WithSimulatedHours price the leaving car by its requested hours instead of the elapsed time, for simulation and test only
+func WithSimulatedHours() Option {
+	return func(o *options) {
+		o.simulateHours = true
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		tariff: tariff.Default(),
+		clock:  clock.System(),
+		grace:  15 * time.Minute,
+
+		currency: types.DefaultCurrency,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,198 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+// maxReportRows keep hourly report over years from building huge response
+const maxReportRows = 5000
+
+// occupancy is the time single vehicle hold its slot
+type occupancy struct {
+	entry, exit time.Time
+}
+
+// reportOf summarize the lot activity per period of the query, car still parked hold its
+// slot until now
+func reportOf(query types.ReportQuery, sessions []types.Session, parked []*types.Car, ledger types.Ledger, capacity int, currency string, now time.Time) (report types.Report, err error) {
+	period := strings.ToLower(query.GetPeriod())
+	if !isReportPeriod(period) {
+		err = fmt.Errorf("%w: unknown report period %s, expected one of %s", contract.ErrInvalidRequest, period, strings.Join(types.ReportPeriods, ", "))
+		return
+	}
+
+	occupancies := make([]occupancy, 0, len(sessions)+len(parked))
+	for _, session := range sessions {
+		occupancies = append(occupancies, occupancy{entry: session.EntryAt, exit: session.ExitAt})
+	}
+	for _, car := range parked {
+		if car != nil {
+			occupancies = append(occupancies, occupancy{entry: car.ParkingAt, exit: now})
+		}
+	}
+
+	from, to := query.From, query.To
+	if to.IsZero() {
+		to = now
+	}
+	if from.IsZero() {
+		from = to
+		for _, o := range occupancies {
+			if o.entry.Before(from) {
+				from = o.entry
+			}
+		}
+		for _, entry := range ledger {
+			if !entry.At.IsZero() && entry.At.Before(from) {
+				from = entry.At
+			}
+		}
+	}
+
+	if to.Before(from) {
+		err = fmt.Errorf("%w: report must end after it start", contract.ErrInvalidRequest)
+		return
+	}
+
+	windows := []window{}
+	for start := types.PeriodStart(period, from); start.Before(to); start = types.NextPeriod(period, start) {
+		if len(windows) == maxReportRows {
+			err = fmt.Errorf("%w: report longer than %d %s row, narrow the range", contract.ErrInvalidRequest, maxReportRows, period)
+			return
+		}
+
+		rowStart, rowEnd := start, types.NextPeriod(period, start)
+		if rowStart.Before(from) {
+			rowStart = from
+		}
+		if rowEnd.After(to) {
+			rowEnd = to
+		}
+		windows = append(windows, window{start: rowStart, end: rowEnd})
+	}
+
+	report = types.Report{
+		Period:   period,
+		Capacity: capacity,
+		Rows:     reportRowsOf(windows, sessions, occupancies, ledger, capacity, currency),
+		Total:    reportRowsOf([]window{{start: from, end: to}}, sessions, occupancies, ledger, capacity, currency)[0],
+	}
+	return report, nil
+}
+
+// window is the range of single report row, end excluded
+type window struct {
+	start, end time.Time
+}
+
+// reportRowsOf summarize the activity of every window, the windows are in order and do not
+// overlap so every session and ledger entry is bucketed once instead of scanned per row
+func reportRowsOf(windows []window, sessions []types.Session, occupancies []occupancy, ledger types.Ledger, capacity int, currency string) []types.ReportRow {
+	rows := make([]types.ReportRow, len(windows))
+	for i, w := range windows {
+		rows[i] = types.ReportRow{Start: w.start, End: w.end, Revenue: money.FromMinor(0, currency)}
+	}
+
+	rowOf := func(at time.Time) (int, bool) {
+		i := sort.Search(len(windows), func(i int) bool { return windows[i].end.After(at) })
+		return i, i < len(windows) && !at.Before(windows[i].start)
+	}
+
+	// revenue is credited by payment and debited by refund and void
+	for _, entry := range ledger {
+		i, ok := rowOf(entry.At)
+		if !ok {
+			continue
+		}
+		if entry.Credit == types.AccountRevenue {
+			rows[i].Revenue = rows[i].Revenue.Add(entry.Amount)
+		}
+		if entry.Debit == types.AccountRevenue {
+			rows[i].Revenue = rows[i].Revenue.Sub(entry.Amount)
+		}
+	}
+
+	parked := make([]time.Duration, len(windows))
+	for _, session := range sessions {
+		if i, ok := rowOf(session.ExitAt); ok {
+			rows[i].Sessions++
+			parked[i] += session.Duration()
+		}
+	}
+
+	peaks := peakOccupancies(windows, occupancies)
+	for i := range rows {
+		rows[i].PeakOccupancy = peaks[i]
+		if rows[i].Sessions > 0 {
+			rows[i].AverageMinutes = math.Round(parked[i].Minutes()/float64(rows[i].Sessions)*10) / 10
+		}
+		if capacity > 0 {
+			rows[i].Turnover = math.Round(float64(rows[i].Sessions)/float64(capacity)*100) / 100
+		}
+	}
+	return rows
+}
+
+// peakOccupancies sweep every entry and exit once along the windows, exit is counted first
+// when both happen at the same time as the leaving car free the slot for the next one
+func peakOccupancies(windows []window, occupancies []occupancy) []int {
+	type event struct {
+		at    time.Time
+		delta int
+	}
+
+	// car leaving the moment it entered never raise the peak, keep it off the count
+	events := make([]event, 0, 2*len(occupancies))
+	for _, o := range occupancies {
+		if o.exit.After(o.entry) {
+			events = append(events, event{at: o.entry, delta: 1}, event{at: o.exit, delta: -1})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].at.Before(events[j].at)
+	})
+
+	peaks := make([]int, len(windows))
+	occupied, next := 0, 0
+	for i, w := range windows {
+		// car already parked at the window start, the one entering right at the start of
+		// empty window is not in it
+		for ; next < len(events); next++ {
+			e := events[next]
+			if e.at.After(w.start) || (e.at.Equal(w.start) && e.delta > 0 && !w.start.Before(w.end)) {
+				break
+			}
+			occupied += e.delta
+		}
+
+		peaks[i] = occupied
+		for ; next < len(events) && events[next].at.Before(w.end); next++ {
+			occupied += events[next].delta
+			if occupied > peaks[i] {
+				peaks[i] = occupied
+			}
+		}
+	}
+	return peaks
+}
+
+func isReportPeriod(period string) bool {
+	for _, known := range types.ReportPeriods {
+		if period == known {
+			return true
+		}
+	}
+	return false
+}
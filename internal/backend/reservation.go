@@ -0,0 +1,103 @@
+package backend
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+// checkReservation validate the reservation request, pinned one is replayed from the log,
+// its plate may predate the current rule and its window is allowed to be already passed
+func checkReservation(request types.ReservationDTO, at time.Time, grace time.Duration, pinned bool) (class string, err error) {
+	if !pinned {
+		if err = validPlate(request.PoliceNumber); err != nil {
+			return
+		}
+	}
+
+	if class, err = vehicleClassOf(types.CarDTO{Class: request.Class}); err != nil {
+		return
+	}
+
+	if !request.To.After(request.From) {
+		err = fmt.Errorf("%w: reservation must end after it start", contract.ErrInvalidRequest)
+		return
+	}
+
+	if !pinned && !at.Before(request.From.Add(grace)) {
+		err = fmt.Errorf("%w: reservation start %s is already passed", contract.ErrInvalidRequest, request.From.Format(time.RFC3339))
+		return
+	}
+	return
+}
+
+// reservableSlot validate the requested slot number for reservation
+func reservableSlot(store []*types.Car, slots []types.Slot, capacity int, number int, class string) (index int, err error) {
+	if number < 1 || number > capacity {
+		err = fmt.Errorf("%w: %d", contract.ErrSlotNotFound, number)
+		return
+	}
+
+	index = number - 1
+	if store[index] != nil || !slots[index].Available() || slots[index].Reserved != nil {
+		err = fmt.Errorf("%w: slot %d is not available", contract.ErrSlotOccupied, number)
+		return
+	}
+
+	if !types.Fits(slots[index].Class, class) {
+		err = fmt.Errorf("%w: %s does not fit %s slot", contract.ErrInvalidRequest, class, slots[index].Class)
+		return
+	}
+	return
+}
+
+// newReservation hold the slot number for the request, pinned is the reservation already in the log
+func newReservation(request types.ReservationDTO, class string, number int, grace time.Duration, pinned *types.Reservation) types.Reservation {
+	if pinned != nil {
+		return *pinned
+	}
+
+	return types.Reservation{
+		Id:           uuid.NewString(),
+		PoliceNumber: request.GetPoliceNumber(),
+		Class:        class,
+		Slot:         number,
+		From:         request.From,
+		To:           request.To,
+		ExpireAt:     request.From.Add(grace),
+		RequestId:    request.RequestId,
+	}
+}
+
+// walkIn accept the slot that is not held for reservation at t
+func walkIn(at time.Time) func(slot types.Slot) bool {
+	return func(slot types.Slot) bool {
+		return !slot.Held(at)
+	}
+}
+
+// unreserved accept the slot without reservation, each slot keep single reservation
+func unreserved(slot types.Slot) bool {
+	return slot.Reserved == nil
+}
+
+// relocated copy the reservation onto the slot index
+func relocated(reservation *types.Reservation, index int) *types.Reservation {
+	moved := *reservation
+	moved.Slot = index + 1
+	return &moved
+}
+
+// statusSlots copy the slot for status, reservation past its grace period is already released
+func statusSlots(slots []types.Slot, at time.Time) []types.Slot {
+	copied := append([]types.Slot(nil), slots...)
+	for i, slot := range copied {
+		if slot.Reserved != nil && !slot.Reserved.Active(at) {
+			copied[i].Reserved = nil
+		}
+	}
+	return copied
+}
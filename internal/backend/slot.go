@@ -0,0 +1,231 @@
+package backend
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/plate"
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+// slotsOf validate the lot layout and expand it into numbered slot
+func slotsOf(layout types.LotLayout) (slots []types.Slot, err error) {
+	for _, group := range layout {
+		if !types.ValidClass(group.Class) {
+			err = fmt.Errorf("%w: unknown slot class %s", contract.ErrInvalidRequest, group.Class)
+			return
+		}
+
+		if group.Count < 1 {
+			err = fmt.Errorf("%w: slot count of %s must be at least 1", contract.ErrInvalidRequest, group.Class)
+			return
+		}
+
+		if group.Level < 0 {
+			err = fmt.Errorf("%w: slot level must not be negative", contract.ErrInvalidRequest)
+			return
+		}
+
+		// zone is part of the label, keep it readable
+		for _, r := range group.Zone {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				err = fmt.Errorf("%w: slot zone %s must be alphanumeric", contract.ErrInvalidRequest, group.Zone)
+				return
+			}
+		}
+	}
+
+	if layout.Capacity() < 1 {
+		err = fmt.Errorf("%w: parking cap must be at least 1", contract.ErrInvalidRequest)
+		return
+	}
+
+	return layout.Slots(), nil
+}
+
+// resizeSlots plan the slot list of the resized lot, parked car keep its slot number.
+// Removed slot still occupied stay at the tail as draining slot when drain is set
+func resizeSlots(layout types.LotLayout, drain bool, store []*types.Car, slots []types.Slot) (newStore []*types.Car, newSlots []types.Slot, err error) {
+	resized, errLayout := slotsOf(layout)
+	if errLayout != nil {
+		return nil, nil, errLayout
+	}
+
+	size := len(resized)
+	blocked := []string{}
+	for i, car := range store {
+		if car == nil {
+			continue
+		}
+
+		switch {
+		case i < len(resized):
+			if !types.Fits(resized[i].Class, car.GetClass()) {
+				blocked = append(blocked, fmt.Sprintf("%s on slot %d does not fit %s slot", car.PoliceNumber, i+1, resized[i].Class))
+			}
+		case drain:
+			size = i + 1
+		default:
+			blocked = append(blocked, fmt.Sprintf("%s on slot %d", car.PoliceNumber, i+1))
+		}
+	}
+
+	// reserved slot can not be drained, the car is not there yet
+	for i, slot := range slots {
+		if slot.Reserved == nil {
+			continue
+		}
+
+		if i >= len(resized) {
+			blocked = append(blocked, fmt.Sprintf("slot %d reserved for %s", i+1, slot.Reserved.PoliceNumber))
+		} else if !types.Fits(resized[i].Class, slot.Reserved.Class) {
+			blocked = append(blocked, fmt.Sprintf("slot %d reserved for %s does not fit %s slot", i+1, slot.Reserved.PoliceNumber, resized[i].Class))
+		}
+	}
+
+	if len(blocked) > 0 {
+		err = fmt.Errorf("%w: %s", contract.ErrSlotOccupied, strings.Join(blocked, ", "))
+		return
+	}
+
+	newStore = make([]*types.Car, size)
+	copy(newStore, store)
+
+	newSlots = make([]types.Slot, size)
+	for i := range newSlots {
+		if i < len(resized) {
+			newSlots[i] = resized[i]
+		} else {
+			newSlots[i] = slots[i]
+			newSlots[i].Draining = true
+		}
+
+		// slot under maintenance stay disabled and reservation keep its slot
+		if i < len(slots) {
+			newSlots[i].Maintenance = slots[i].Maintenance
+			newSlots[i].Reserved = slots[i].Reserved
+		}
+
+		if car := newStore[i]; car != nil {
+			car.SlotLabel = newSlots[i].Label
+		}
+	}
+	return
+}
+
+// trimDrained drop the empty draining slot at the tail
+func trimDrained(store []*types.Car, slots []types.Slot, capacity int) ([]*types.Car, []types.Slot) {
+	last := len(store)
+	for last > capacity && store[last-1] == nil {
+		last--
+	}
+	return store[:last], slots[:last]
+}
+
+// maintainedSlot validate the slot to be disabled or enabled, draining slot is already out of the lot
+func maintainedSlot(number int, capacity int) (index int, err error) {
+	if capacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	if number < 1 || number > capacity {
+		err = fmt.Errorf("%w: %d", contract.ErrSlotNotFound, number)
+		return
+	}
+	return number - 1, nil
+}
+
+// slotDetailOf look up the slot of the number including the draining one, the car is copied
+// since caller read it after the lock is released
+func slotDetailOf(store []*types.Car, slots []types.Slot, capacity int, number int, at time.Time) (detail types.SlotDetail, err error) {
+	if capacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	if number < 1 || number > len(slots) {
+		err = fmt.Errorf("%w: %d", contract.ErrSlotNotFound, number)
+		return
+	}
+
+	detail.Slot = statusSlots(slots[number-1:number], at)[0]
+	if car := store[number-1]; car != nil {
+		c := *car
+		detail.Car = &c
+	}
+	return
+}
+
+// restoredSlots return the snapshot slot, snapshot written before slot was typed only has car slot
+// and the one before slot was labeled is flat lot
+func restoredSlots(slots []types.Slot, capacity int) []types.Slot {
+	if len(slots) == 0 {
+		return types.UniformLayout(capacity).Slots()
+	}
+
+	for i := range slots {
+		if slots[i].Label == "" {
+			slots[i].Label = strconv.Itoa(slots[i].Number)
+		}
+	}
+	return slots
+}
+
+// validPlate reject malformed police number of the request
+func validPlate(policeNumber string) error {
+	if _, errPlate := plate.Normalize(policeNumber); errPlate != nil {
+		return fmt.Errorf("%w: %v", contract.ErrInvalidRequest, errPlate)
+	}
+	return nil
+}
+
+// validVehicle check the plate and attributes of live park request, replayed one was accepted
+// by the build that wrote the log and may predate the current rule
+func validVehicle(request types.CarDTO) error {
+	if err := validPlate(request.PoliceNumber); err != nil {
+		return err
+	}
+	return validAttributes(request)
+}
+
+// longest vehicle attribute accepted, photo reference may be url
+const (
+	maxAttributeLength = 32
+	maxPhotoLength     = 512
+)
+
+// validColor reject empty color on color query
+func validColor(color string) error {
+	if types.ColorKey(color) == "" {
+		return fmt.Errorf("%w: color is required", contract.ErrInvalidRequest)
+	}
+	return nil
+}
+
+// validAttributes reject too long vehicle attribute of the request
+func validAttributes(request types.CarDTO) error {
+	for name, value := range map[string]string{"color": request.Color, "make": request.Make, "model": request.Model} {
+		if len(strings.TrimSpace(value)) > maxAttributeLength {
+			return fmt.Errorf("%w: vehicle %s must be at most %d character", contract.ErrInvalidRequest, name, maxAttributeLength)
+		}
+	}
+
+	if len(strings.TrimSpace(request.Photo)) > maxPhotoLength {
+		return fmt.Errorf("%w: vehicle photo reference must be at most %d character", contract.ErrInvalidRequest, maxPhotoLength)
+	}
+	return nil
+}
+
+// vehicleClassOf validate the class of the incoming vehicle
+func vehicleClassOf(request types.CarDTO) (class string, err error) {
+	class = request.GetClass()
+	if !types.ValidClass(class) {
+		err = fmt.Errorf("%w: unknown vehicle class %s", contract.ErrInvalidRequest, class)
+	}
+	return
+}
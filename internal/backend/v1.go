@@ -1,31 +1,137 @@
 package backend
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/clock"
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/plate"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/tariff"
 	"github.com/khafidprayoga/parking-app/internal/types"
 )
 
 type ParkingServiceV1 struct {
 	mu sync.RWMutex
 
-	lotCapacity int            `json:"lot_capacity"`
-	store       []*types.Car   `json:"store"`
-	revenue     float64        `json:"revenue"`
-	tx          map[string]int `json:"-"`
+	lotCapacity int
+	store       []*types.Car
+	slots       []types.Slot
+	tx          map[string]int
+
+	// parked is the slot index per plate key
+	parked map[string]int
+
+	// sessions is the completed parking history, oldest first
+	sessions []types.Session
+
+	// members is the monthly pass per plate key
+	members map[string]types.Member
+
+	// ledger is every payment, refund and void, revenue is computed from it
+	ledger   types.Ledger
+	currency string
+
+	tariff  tariff.Tariff
+	loyalty tariff.Loyalty
+	clock   clock.Clock
+	grace   time.Duration
+	journal journal
+
+	// simulateHours accept the leave hours override
+	simulateHours bool
 }
 
-func NewParkingService() *ParkingServiceV1 {
+func NewParkingService(opts ...Option) *ParkingServiceV1 {
+	o := newOptions(opts)
 	return &ParkingServiceV1{
-		tx: make(map[string]int),
+		tx:      make(map[string]int),
+		parked:  make(map[string]int),
+		members: make(map[string]types.Member),
+		tariff:  o.tariff,
+		loyalty: o.loyalty,
+
+		currency: o.currency,
+		clock:    o.clock,
+		grace:    o.grace,
+		journal:  journal{db: o.db},
+
+		simulateHours: o.simulateHours,
 	}
 }
 
-func (p *ParkingServiceV1) Status() (_ []byte, err error) {
+// Restore load the last snapshot and replay the write-ahead log on top of it, no-op without store
+func (p *ParkingServiceV1) Restore() (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, entries, errLoad := p.journal.load()
+	if errLoad != nil {
+		return errLoad
+	}
+
+	if state != nil {
+		if err = reprice(state, p.currency); err != nil {
+			return
+		}
+
+		// car list longer than capacity is draining slot
+		if len(state.CarList) < state.LotCapacity {
+			err = fmt.Errorf("failed to restore parking state: corrupted car list size")
+			return
+		}
+
+		slots := restoredSlots(state.Slots, state.LotCapacity)
+		if len(slots) != len(state.CarList) {
+			err = fmt.Errorf("failed to restore parking state: corrupted slot list size")
+			return
+		}
+
+		p.lotCapacity = state.LotCapacity
+		p.store = state.CarList
+		p.slots = slots
+		p.ledger = ledgerOf(state)
+		p.tx = txOf(state)
+		p.sessions = state.Sessions
+		if state.Members != nil {
+			p.members = state.Members
+		}
+
+		p.parked = make(map[string]int)
+		for i, car := range p.store {
+			if car != nil {
+				p.parked[plate.Key(car.PoliceNumber)] = i
+			}
+		}
+	}
+
+	return p.journal.replay(p, entries)
+}
+
+// Compact fold the write-ahead log into a fresh snapshot
+func (p *ParkingServiceV1) Compact() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.journal.compact(store.Snapshot{
+		LotCapacity: p.lotCapacity,
+		CarList:     p.store,
+		Slots:       p.slots,
+		Revenue:     p.ledger.Revenue().In(p.currency),
+		Tx:          p.tx,
+		Sessions:    p.sessions,
+		Members:     p.members,
+		Ledger:      p.ledger,
+		Currency:    p.currency,
+	})
+}
+
+func (p *ParkingServiceV1) Status() (status types.AppStatus, err error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
@@ -34,42 +140,247 @@ func (p *ParkingServiceV1) Status() (_ []byte, err error) {
 		countAllTx += perCarTxHistory
 	}
 
-	state := types.AppStatus{
-		Revenue:            p.revenue,
+	// copy the car, caller read it after the lock is released
+	carList := make([]*types.Car, len(p.store))
+	for i, car := range p.store {
+		if car != nil {
+			c := *car
+			carList[i] = &c
+		}
+	}
+
+	slots := statusSlots(p.slots, p.clock.Now())
+	status = types.AppStatus{
+		Revenue:            p.ledger.Revenue().In(p.currency),
 		LotParkingCapacity: p.lotCapacity,
 		TxCount:            countAllTx,
-		CarList:            p.store,
+		CarList:            carList,
+		Slots:              slots,
+		Occupancy:          types.OccupancyOf(slots, p.store),
 	}
 
-	dataBytes, errMarshall := json.Marshal(state)
-	if errMarshall != nil {
-		err = fmt.Errorf("failed to marshall parking data")
+	return status, nil
+}
+
+func (p *ParkingServiceV1) FindCar(policeNumber string) (car types.Car, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
 		return
 	}
 
-	// stub: simulating deadline for conn lifetime
-	//time.Sleep(10 * time.Second)
+	index, exist := p.parked[plate.Key(policeNumber)]
+	if !exist {
+		err = fmt.Errorf("%w: %s", contract.ErrCarNotFound, types.CarDTO{PoliceNumber: policeNumber}.GetPoliceNumber())
+		return
+	}
+	return *p.store[index], nil
+}
 
-	return dataBytes, nil
+func (p *ParkingServiceV1) SearchCars(filter types.CarFilter) (cars []types.Car, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	cars = []types.Car{}
+	for _, car := range p.store {
+		if car != nil && filter.Match(*car) {
+			cars = append(cars, *car)
+		}
+	}
+	return cars, nil
+}
+
+func (p *ParkingServiceV1) CarsByColor(color string) (cars []types.Car, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	if err = validColor(color); err != nil {
+		return
+	}
+
+	cars = []types.Car{}
+	for _, car := range p.store {
+		if car != nil && types.ColorKey(car.Color) == types.ColorKey(color) {
+			cars = append(cars, *car)
+		}
+	}
+	return cars, nil
+}
+
+func (p *ParkingServiceV1) SlotDetail(number int) (detail types.SlotDetail, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return slotDetailOf(p.store, p.slots, p.lotCapacity, number, p.clock.Now())
+}
+
+func (p *ParkingServiceV1) History(query types.HistoryQuery) (sessions []types.Session, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	sessions = []types.Session{}
+	for _, session := range p.sessions {
+		if query.Match(session) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
 }
 
-func (p *ParkingServiceV1) OpenParkingArea(parkingCap int) (err error) {
+func (p *ParkingServiceV1) Report(query types.ReportQuery) (report types.Report, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+	return reportOf(query, p.sessions, p.store, p.ledger, p.lotCapacity, p.currency, p.clock.Now())
+}
+
+func (p *ParkingServiceV1) OpenParkingArea(layout types.LotLayout) (err error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if parkingCap < 1 {
-		err = fmt.Errorf("parking cap must be at least 1")
+	if err = p.journal.ready(); err != nil {
 		return
 	}
 
+	if err = p.openArea(layout); err != nil {
+		return
+	}
+
+	return p.journal.record(opOpen, p.clock.Now(), openEntry{Capacity: layout.Capacity(), Layout: layout})
+}
+
+func (p *ParkingServiceV1) openArea(layout types.LotLayout) (err error) {
+	slots, errLayout := slotsOf(layout)
+	if errLayout != nil {
+		return errLayout
+	}
+
 	if p.lotCapacity > 0 {
-		err = fmt.Errorf("failed, already initalize the parking lot capacity")
+		err = contract.ErrAlreadyInitialized
+		return
+	}
+
+	p.lotCapacity = len(slots)
+	p.store = make([]*types.Car, len(slots))
+	p.slots = slots
+
+	return
+}
+
+func (p *ParkingServiceV1) ResizeParkingArea(layout types.LotLayout, drain bool) (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	if err = p.resize(layout, drain, at); err != nil {
+		return
+	}
+
+	return p.journal.record(opResize, at, resizeEntry{Layout: layout, Drain: drain})
+}
+
+func (p *ParkingServiceV1) resize(layout types.LotLayout, drain bool, at time.Time) (err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	// lapsed reservation no longer hold the slot the layout remove
+	p.settleReservations(at)
+
+	newStore, newSlots, errResize := resizeSlots(layout, drain, p.store, p.slots)
+	if errResize != nil {
+		return errResize
+	}
+
+	p.lotCapacity = layout.Capacity()
+	p.store = newStore
+	p.slots = newSlots
+	return
+}
+
+func (p *ParkingServiceV1) DisableSlot(number int, reason string, force bool) (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	if err = p.disable(number, reason, force, at); err != nil {
+		return
+	}
+
+	return p.journal.record(opDisable, at, slotEntry{Number: number, Reason: reason, Force: force})
+}
+
+func (p *ParkingServiceV1) disable(number int, reason string, force bool, at time.Time) (err error) {
+	index, errSlot := maintainedSlot(number, p.lotCapacity)
+	if errSlot != nil {
+		return errSlot
+	}
+
+	p.settleReservations(at)
+
+	if car := p.store[index]; car != nil && !force {
+		err = fmt.Errorf("%w: %s on slot %d, force to disable it anyway", contract.ErrSlotOccupied, car.PoliceNumber, number)
 		return
 	}
 
-	p.lotCapacity = parkingCap
-	p.store = make([]*types.Car, parkingCap)
+	if reserved := p.slots[index].Reserved; reserved != nil && !force {
+		err = fmt.Errorf("%w: slot %d reserved for %s, force to disable it anyway", contract.ErrSlotOccupied, number, reserved.PoliceNumber)
+		return
+	}
+
+	// forced disable drop the reservation, replaying the logged disable drop it again
+	p.slots[index].Reserved = nil
+	p.slots[index].Maintenance = &types.Maintenance{Reason: reason, Since: at}
+	return
+}
+
+func (p *ParkingServiceV1) EnableSlot(number int) (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
 
+	if err = p.enable(number); err != nil {
+		return
+	}
+
+	return p.journal.record(opEnable, p.clock.Now(), slotEntry{Number: number})
+}
+
+func (p *ParkingServiceV1) enable(number int) (err error) {
+	index, errSlot := maintainedSlot(number, p.lotCapacity)
+	if errSlot != nil {
+		return errSlot
+	}
+
+	p.slots[index].Maintenance = nil
 	return
 }
 
@@ -77,86 +388,456 @@ func (p *ParkingServiceV1) EnterArea(request types.CarDTO) (areaId int, err erro
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if len(request.PoliceNumber) == 0 {
-		err = fmt.Errorf("failed, police number is empty")
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	if err = validVehicle(request); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	if areaId, err = p.enter(request, at); err != nil {
 		return
 	}
 
+	if err = p.journal.record(opEnter, at, enterEntry{Request: request}); err != nil {
+		return 0, err
+	}
+	return
+}
+
+func (p *ParkingServiceV1) enter(request types.CarDTO, at time.Time) (areaId int, err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	class, errClass := vehicleClassOf(request)
+	if errClass != nil {
+		return 0, errClass
+	}
+
+	p.settleReservations(at)
+
 	// validate if  car number not already exist on the parking area
-	for _, car := range p.store {
-		if car != nil {
-			if strings.EqualFold(car.PoliceNumber, request.PoliceNumber) {
-				err = fmt.Errorf("failed, already parked the parking lot capacity")
-				return
-			}
+	if _, exist := p.parked[plate.Key(request.PoliceNumber)]; exist {
+		err = fmt.Errorf("%w: %s", contract.ErrAlreadyParked, request.GetPoliceNumber())
+		return
+	}
+
+	// car with reservation take its slot, unless walk-in car still park on it
+	index, reserved := p.reservationOf(request.PoliceNumber)
+	if reserved {
+		if !types.Fits(p.slots[index].Class, class) {
+			err = fmt.Errorf("%w: %s does not fit reserved %s slot", contract.ErrInvalidRequest, class, p.slots[index].Class)
+			return
+		}
+		p.slots[index].Reserved = nil
+		if p.store[index] != nil || !p.slots[index].Available() {
+			index = -1
 		}
 	}
+	if index < 0 {
+		if index = p.nearest(class, walkIn(at)); index < 0 {
+			err = fmt.Errorf("%w: no free slot for %s", contract.ErrLotFull, class)
+			return
+		}
+	}
+
+	areaId = index + 1
+	p.store[index] = &types.Car{
+		Id:           request.RequestId,
+		AreaNumber:   areaId,
+		SlotLabel:    p.slots[index].Label,
+		Color:        request.GetColor(),
+		Make:         strings.TrimSpace(request.Make),
+		Model:        strings.TrimSpace(request.Model),
+		Photo:        strings.TrimSpace(request.Photo),
+		Class:        class,
+		PoliceNumber: request.GetPoliceNumber(),
+		ParkingAt:    at,
+		ExitAt:       nil,
+	}
+	p.parked[plate.Key(request.PoliceNumber)] = index
+	return
+}
 
+// nearest return the nearest free compatible slot from the door gateway that accept take, -1 when none
+func (p *ParkingServiceV1) nearest(class string, accept func(slot types.Slot) bool) int {
 	for index, car := range p.store {
-		// allocating nearest parking lot from the door gateway
-		if car == nil {
-			id := index + 1
-
-			p.store[index] = &types.Car{
-				Id:           request.RequestId,
-				AreaNumber:   id,
-				PoliceNumber: request.GetPoliceNumber(),
-				ParkingAt:    time.Now(),
-				ExitAt:       nil,
-			}
+		if car == nil && p.slots[index].Available() && types.Fits(p.slots[index].Class, class) && accept(p.slots[index]) {
+			return index
+		}
+	}
+	return -1
+}
+
+func (p *ParkingServiceV1) Reserve(request types.ReservationDTO) (reservation types.Reservation, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	if reservation, err = p.reserve(request, at, nil); err != nil {
+		return
+	}
+
+	if err = p.journal.record(opReserve, at, reserveEntry{Request: request, Reserved: reservation}); err != nil {
+		return types.Reservation{}, err
+	}
+	return
+}
+
+// reserve hold slot for the plate, pinned is the already made reservation when replaying the log
+func (p *ParkingServiceV1) reserve(request types.ReservationDTO, at time.Time, pinned *types.Reservation) (reservation types.Reservation, err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
 
-			areaId = id
-			return areaId, nil
+	class, errCheck := checkReservation(request, at, p.grace, pinned != nil)
+	if errCheck != nil {
+		return reservation, errCheck
+	}
+
+	p.settleReservations(at)
+	if _, ok := p.reservationOf(request.PoliceNumber); ok {
+		err = fmt.Errorf("%w: %s", contract.ErrAlreadyReserved, request.GetPoliceNumber())
+		return
+	}
+
+	if pinned != nil {
+		request.Slot = pinned.Slot
+	}
+
+	index := -1
+	if request.Slot > 0 {
+		if index, err = reservableSlot(p.store, p.slots, p.lotCapacity, request.Slot, class); err != nil {
+			return
 		}
+	} else if index = p.nearest(class, unreserved); index < 0 {
+		err = fmt.Errorf("%w: no free slot for %s", contract.ErrLotFull, class)
+		return
 	}
 
-	// default state when loop is not returned immediately
-	err = fmt.Errorf("parking lot capacity is full")
+	reservation = newReservation(request, class, index+1, p.grace, pinned)
+	held := reservation
+	p.slots[index].Reserved = &held
 	return
 }
 
-func (p *ParkingServiceV1) LeaveArea(req types.CarDTO) (exitedCar types.Car, err error) {
+func (p *ParkingServiceV1) CancelReservation(policeNumber string) (err error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if req.Hours < 1 {
-		err = fmt.Errorf("failed, parking must be at least 1 hour")
+	if err = p.journal.ready(); err != nil {
 		return
 	}
-	carDetail := types.Car{}
-	carIndex := -1
 
-	for i, car := range p.store {
-		if car != nil {
-			if strings.EqualFold(car.PoliceNumber, req.PoliceNumber) {
-				carDetail = *car
-				carIndex = i
-				break
+	at := p.clock.Now()
+	if err = p.cancel(policeNumber, at); err != nil {
+		return
+	}
+
+	return p.journal.record(opCancel, at, cancelEntry{PoliceNumber: policeNumber})
+}
+
+func (p *ParkingServiceV1) cancel(policeNumber string, at time.Time) (err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	p.settleReservations(at)
+	index, ok := p.reservationOf(policeNumber)
+	if !ok {
+		err = fmt.Errorf("%w: %s", contract.ErrNoReservation, policeNumber)
+		return
+	}
+
+	p.slots[index].Reserved = nil
+	return
+}
+
+// reservationOf return the slot index held for the plate
+func (p *ParkingServiceV1) reservationOf(policeNumber string) (index int, ok bool) {
+	for i, slot := range p.slots {
+		if slot.Reserved != nil && plate.Equal(slot.Reserved.PoliceNumber, policeNumber) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// settleReservations release the reservation whose car did not arrive within the grace period
+// or whose window ended, and move the started one whose slot is still taken by walk-in car
+// to the nearest free slot
+func (p *ParkingServiceV1) settleReservations(at time.Time) {
+	for i := range p.slots {
+		reserved := p.slots[i].Reserved
+		switch {
+		case reserved == nil:
+		case !reserved.Active(at):
+			p.slots[i].Reserved = nil
+		case reserved.Holds(at) && p.store[i] != nil:
+			if moved := p.nearest(reserved.Class, unreserved); moved >= 0 {
+				p.slots[i].Reserved = nil
+				p.slots[moved].Reserved = relocated(reserved, moved)
 			}
 		}
 	}
+}
+
+func (p *ParkingServiceV1) LeaveArea(req types.CarDTO) (exitedCar types.Car, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	if exitedCar, err = p.leave(req, at, nil); err != nil {
+		return
+	}
+
+	if err = p.journal.record(opLeave, at, leaveEntry{Request: req, Exited: exitedCar}); err != nil {
+		return types.Car{}, err
+	}
+	return
+}
+
+// leave free the car slot, priced is the already charged car when replaying the log
+func (p *ParkingServiceV1) leave(req types.CarDTO, at time.Time, priced *types.Car) (exitedCar types.Car, err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	if req.Hours < 0 {
+		err = contract.ErrInvalidDuration
+		return
+	}
+
+	// the override is a simulation aid, a live lot bill the elapsed time
+	if req.Hours > 0 && priced == nil && !p.simulateHours {
+		err = fmt.Errorf("%w: hours override is disabled on this server", contract.ErrInvalidRequest)
+		return
+	}
+
+	method, errMethod := methodOf(req)
+	if errMethod != nil {
+		return types.Car{}, errMethod
+	}
 
-	if carIndex < 0 {
-		err = fmt.Errorf("car with police number %s does not exist", req.PoliceNumber)
+	carIndex, exist := p.parked[plate.Key(req.PoliceNumber)]
+	if !exist {
+		err = fmt.Errorf("%w: %s", contract.ErrCarNotFound, req.GetPoliceNumber())
 		return
 	}
+	carDetail := *p.store[carIndex]
 
-	start := carDetail.ParkingAt
-	end := start.Add(time.Duration(req.Hours) * time.Hour)
-	carDetail.ExitAt = &end
-	carDetail.Cost = p.calculateCost(req.Hours)
+	carDetail.PaymentId, carDetail.Method = uuid.NewString(), method
+
+	if priced == nil {
+		// elapsed since parked, hours only override the billed duration for simulation
+		start := carDetail.ParkingAt
+		end := at
+		if req.Hours > 0 {
+			end = start.Add(time.Duration(req.Hours) * time.Hour)
+		}
+		cost, errCost := priceIn(p.tariff.Cost(start, end), p.currency)
+		if errCost != nil {
+			return types.Car{}, errCost
+		}
+		carDetail.ExitAt = &at
+		carDetail.Discount, carDetail.DiscountReason = p.discountOf(plate.Key(req.PoliceNumber), cost, at)
+		carDetail.Cost = cost.Sub(carDetail.Discount)
+	} else {
+		// replay keep the charged price, the tariff may have changed since
+		charged, errCharged := repriced(*priced, p.currency)
+		if errCharged != nil {
+			return types.Car{}, errCharged
+		}
+		carDetail.ExitAt = charged.ExitAt
+		carDetail.Cost = charged.Cost
+		carDetail.Discount = charged.Discount
+		carDetail.DiscountReason = charged.DiscountReason
+
+		// log written before the ledger has no payment id, it keep the fresh one
+		if priced.PaymentId != "" {
+			carDetail.PaymentId, carDetail.Method = priced.PaymentId, priced.Method
+		}
+	}
 
 	// pay the tx cost
-	p.pay(req.GetPoliceNumber())
+	p.pay(plate.Key(req.PoliceNumber))
 
 	// flush
-	p.revenue = p.revenue + carDetail.Cost
+	p.ledger = append(p.ledger, paymentEntry(carDetail, at))
 	p.store[carIndex] = nil
+	delete(p.parked, plate.Key(req.PoliceNumber))
+	p.store, p.slots = trimDrained(p.store, p.slots, p.lotCapacity)
+
+	// keep the completed session for history and receipt
+	p.sessions = append(p.sessions, types.SessionOf(carDetail))
 
 	exitedCar = carDetail
 	return
 }
 
+// discountOf price the member or loyalty discount of the plate key, before its visit is counted
+func (p *ParkingServiceV1) discountOf(key string, cost money.Money, at time.Time) (discount money.Money, reason string) {
+	var member *types.Member
+	if current, ok := p.members[key]; ok {
+		member = &current
+	}
+	return discountOf(cost, member, p.loyalty, p.tx[key], at)
+}
+
+func (p *ParkingServiceV1) AddMember(request types.MemberDTO) (member types.Member, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	if member, err = p.addMember(request, at); err != nil {
+		return
+	}
+
+	if err = p.journal.record(opMember, at, memberEntry{Request: request}); err != nil {
+		return types.Member{}, err
+	}
+	return
+}
+
+// addMember register or renew the monthly pass of the plate
+func (p *ParkingServiceV1) addMember(request types.MemberDTO, at time.Time) (member types.Member, err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	key := plate.Key(request.PoliceNumber)
+	var current *types.Member
+	if existing, ok := p.members[key]; ok {
+		current = &existing
+	}
+
+	if member, err = renewMember(request, current, at); err != nil {
+		return
+	}
+
+	p.members[key] = member
+	return
+}
+
+func (p *ParkingServiceV1) RemoveMember(policeNumber string) (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	if err = p.removeMember(policeNumber); err != nil {
+		return
+	}
+
+	return p.journal.record(opRemoveMember, p.clock.Now(), cancelEntry{PoliceNumber: policeNumber})
+}
+
+func (p *ParkingServiceV1) removeMember(policeNumber string) (err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	key := plate.Key(policeNumber)
+	if _, ok := p.members[key]; !ok {
+		err = fmt.Errorf("%w: %s", contract.ErrMemberNotFound, types.CarDTO{PoliceNumber: policeNumber}.GetPoliceNumber())
+		return
+	}
+
+	delete(p.members, key)
+	return
+}
+
+func (p *ParkingServiceV1) Members() (members []types.Member, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+	return sortedMembers(p.members), nil
+}
+
+func (p *ParkingServiceV1) Refund(request types.RefundDTO) (payment types.Payment, err error) {
+	return p.adjustPayment(types.EntryRefund, opRefund, request)
+}
+
+func (p *ParkingServiceV1) Void(request types.RefundDTO) (payment types.Payment, err error) {
+	return p.adjustPayment(types.EntryVoid, opVoid, request)
+}
+
+// adjustPayment record the refund or void of the payment and return the payment after it
+func (p *ParkingServiceV1) adjustPayment(kind, op string, request types.RefundDTO) (payment types.Payment, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	entry, errAdjust := p.adjust(kind, request, at, nil)
+	if errAdjust != nil {
+		return payment, errAdjust
+	}
+
+	if err = p.journal.record(op, at, adjustEntry{Request: request, Entry: entry}); err != nil {
+		return
+	}
+
+	payment, _ = p.ledger.Payment(entry.PaymentId)
+	return payment, nil
+}
+
+// adjust append the entry reversing the payment, pinned is the entry already in the log
+func (p *ParkingServiceV1) adjust(kind string, request types.RefundDTO, at time.Time, pinned *types.LedgerEntry) (entry types.LedgerEntry, err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	if entry, err = adjustment(p.ledger, kind, request, at, pinned); err != nil {
+		return
+	}
+
+	p.ledger = append(p.ledger, entry)
+	return
+}
+
+func (p *ParkingServiceV1) Payments(query types.PaymentQuery) (report types.PaymentReport, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+	return p.ledger.ReportOf(query, p.currency), nil
+}
+
 func (p *ParkingServiceV1) pay(policeNumber string) {
 	// on existing tx book history
 	if val, ok := p.tx[policeNumber]; ok {
@@ -168,15 +849,3 @@ func (p *ParkingServiceV1) pay(policeNumber string) {
 	// new member
 	p.tx[policeNumber] = 1
 }
-
-func (p *ParkingServiceV1) calculateCost(hours int) float64 {
-	const baseCost = 10
-	const baseCostMinHours = 2
-	if hours <= baseCostMinHours {
-		return baseCost
-	}
-
-	extraHours := hours - 2
-
-	return float64(baseCost + (extraHours * baseCost))
-}
@@ -1,12 +1,20 @@
 package backend
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/google/btree"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/clock"
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/plate"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/tariff"
 	"github.com/khafidprayoga/parking-app/internal/types"
 )
 
@@ -15,21 +23,137 @@ type ParkingServiceV1BTree struct {
 
 	lotCapacity int
 
-	store   []*types.Car
-	hotspot *btree.BTreeG[int]
+	store []*types.Car
+	slots []types.Slot
+
+	// hotspot is the free slot index per slot class
+	hotspot map[string]*btree.BTreeG[int]
+
+	// history is the slot index per plate key of the parked car
 	history map[string]int
 
-	revenue float64
-	tx      map[string]int
+	// reserved is the slot index held per plate key
+	reserved map[string]int
+
+	// colors is the occupied slot index per color key of the parked car
+	colors map[string]*btree.BTreeG[int]
+
+	tx map[string]int
+
+	// sessions is the completed parking history, oldest first
+	sessions []types.Session
+
+	// members is the monthly pass per plate key
+	members map[string]types.Member
+
+	// ledger is every payment, refund and void, revenue is computed from it
+	ledger   types.Ledger
+	currency string
+
+	tariff  tariff.Tariff
+	loyalty tariff.Loyalty
+	clock   clock.Clock
+	grace   time.Duration
+	journal journal
+
+	// simulateHours accept the leave hours override
+	simulateHours bool
 }
 
-func NewParkingServiceBTree() *ParkingServiceV1BTree {
+func NewParkingServiceBTree(opts ...Option) *ParkingServiceV1BTree {
+	o := newOptions(opts)
 	return &ParkingServiceV1BTree{
-		tx: make(map[string]int),
+		tx:      make(map[string]int),
+		members: make(map[string]types.Member),
+		tariff:  o.tariff,
+		loyalty: o.loyalty,
+
+		currency: o.currency,
+		clock:    o.clock,
+		grace:    o.grace,
+		journal:  journal{db: o.db},
+
+		simulateHours: o.simulateHours,
 	}
 }
 
-func (p *ParkingServiceV1BTree) Status() (_ []byte, err error) {
+// Restore load the last snapshot, rebuild the free slot tree and replay the write-ahead log on top of it, no-op without store
+func (p *ParkingServiceV1BTree) Restore() (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, entries, errLoad := p.journal.load()
+	if errLoad != nil {
+		return errLoad
+	}
+
+	if state != nil {
+		if err = reprice(state, p.currency); err != nil {
+			return
+		}
+
+		// car list longer than capacity is draining slot
+		if len(state.CarList) < state.LotCapacity {
+			err = fmt.Errorf("failed to restore parking state: corrupted car list size")
+			return
+		}
+
+		slots := restoredSlots(state.Slots, state.LotCapacity)
+		if len(slots) != len(state.CarList) {
+			err = fmt.Errorf("failed to restore parking state: corrupted slot list size")
+			return
+		}
+
+		p.lotCapacity = state.LotCapacity
+		p.store = state.CarList
+		p.slots = slots
+		p.ledger = ledgerOf(state)
+		p.tx = txOf(state)
+		p.sessions = state.Sessions
+		if state.Members != nil {
+			p.members = state.Members
+		}
+
+		p.history = make(map[string]int)
+		p.colors = make(map[string]*btree.BTreeG[int])
+		for i, car := range p.store {
+			if car != nil {
+				p.history[plate.Key(car.PoliceNumber)] = i
+				p.indexColor(i)
+			}
+		}
+
+		p.reserved = make(map[string]int)
+		for i, slot := range p.slots {
+			if slot.Reserved != nil {
+				p.reserved[plate.Key(slot.Reserved.PoliceNumber)] = i
+			}
+		}
+		p.rebuildHotspot()
+	}
+
+	return p.journal.replay(p, entries)
+}
+
+// Compact fold the write-ahead log into a fresh snapshot
+func (p *ParkingServiceV1BTree) Compact() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.journal.compact(store.Snapshot{
+		LotCapacity: p.lotCapacity,
+		CarList:     p.store,
+		Slots:       p.slots,
+		Revenue:     p.ledger.Revenue().In(p.currency),
+		Tx:          p.tx,
+		Sessions:    p.sessions,
+		Members:     p.members,
+		Ledger:      p.ledger,
+		Currency:    p.currency,
+	})
+}
+
+func (p *ParkingServiceV1BTree) Status() (status types.AppStatus, err error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
@@ -38,46 +162,354 @@ func (p *ParkingServiceV1BTree) Status() (_ []byte, err error) {
 		countAllTx += perCarTxHistory
 	}
 
-	state := types.AppStatus{
-		Revenue:            p.revenue,
+	// copy the car, caller read it after the lock is released
+	carList := make([]*types.Car, len(p.store))
+	for i, car := range p.store {
+		if car != nil {
+			c := *car
+			carList[i] = &c
+		}
+	}
+
+	slots := statusSlots(p.slots, p.clock.Now())
+	status = types.AppStatus{
+		Revenue:            p.ledger.Revenue().In(p.currency),
 		LotParkingCapacity: p.lotCapacity,
 		TxCount:            countAllTx,
-		CarList:            p.store,
+		CarList:            carList,
+		Slots:              slots,
+		Occupancy:          types.OccupancyOf(slots, p.store),
+	}
+
+	return status, nil
+}
+
+func (p *ParkingServiceV1BTree) FindCar(policeNumber string) (car types.Car, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	index, exist := p.history[plate.Key(policeNumber)]
+	if !exist {
+		err = fmt.Errorf("%w: %s", contract.ErrCarNotFound, types.CarDTO{PoliceNumber: policeNumber}.GetPoliceNumber())
+		return
+	}
+	return *p.store[index], nil
+}
+
+func (p *ParkingServiceV1BTree) SearchCars(filter types.CarFilter) (cars []types.Car, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
 	}
 
-	dataBytes, errMarshall := json.Marshal(state)
-	if errMarshall != nil {
-		err = fmt.Errorf("failed to marshall parking data")
+	// color narrow the candidate through its index instead of every slot
+	candidates := p.store
+	if types.ColorKey(filter.Color) != "" {
+		candidates = p.byColor(filter.Color)
+	}
+
+	cars = []types.Car{}
+	for _, car := range candidates {
+		if car != nil && filter.Match(*car) {
+			cars = append(cars, *car)
+		}
+	}
+	return cars, nil
+}
+
+func (p *ParkingServiceV1BTree) CarsByColor(color string) (cars []types.Car, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
 		return
 	}
 
-	// stub: simulating deadline for conn lifetime
-	//time.Sleep(10 * time.Second)
+	if err = validColor(color); err != nil {
+		return
+	}
 
-	return dataBytes, nil
+	cars = []types.Car{}
+	for _, car := range p.byColor(color) {
+		cars = append(cars, *car)
+	}
+	return cars, nil
+}
+
+// byColor return the parked car of the color in slot order from the color index
+func (p *ParkingServiceV1BTree) byColor(color string) (cars []*types.Car) {
+	tree, ok := p.colors[types.ColorKey(color)]
+	if !ok {
+		return
+	}
+
+	tree.Ascend(func(index int) bool {
+		cars = append(cars, p.store[index])
+		return true
+	})
+	return
+}
+
+// indexColor add the parked car slot index into the tree of its color, car without color is not indexed
+func (p *ParkingServiceV1BTree) indexColor(index int) {
+	key := types.ColorKey(p.store[index].Color)
+	if key == "" {
+		return
+	}
+
+	tree, ok := p.colors[key]
+	if !ok {
+		tree = btree.NewOrderedG[int](32)
+		p.colors[key] = tree
+	}
+	tree.ReplaceOrInsert(index)
 }
 
-func (p *ParkingServiceV1BTree) OpenParkingArea(parkingCap int) (err error) {
+// unindexColor remove the leaving car slot index, empty color tree is dropped
+func (p *ParkingServiceV1BTree) unindexColor(index int) {
+	key := types.ColorKey(p.store[index].Color)
+	tree, ok := p.colors[key]
+	if !ok {
+		return
+	}
+
+	tree.Delete(index)
+	if tree.Len() == 0 {
+		delete(p.colors, key)
+	}
+}
+
+func (p *ParkingServiceV1BTree) SlotDetail(number int) (detail types.SlotDetail, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return slotDetailOf(p.store, p.slots, p.lotCapacity, number, p.clock.Now())
+}
+
+func (p *ParkingServiceV1BTree) History(query types.HistoryQuery) (sessions []types.Session, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	sessions = []types.Session{}
+	for _, session := range p.sessions {
+		if query.Match(session) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (p *ParkingServiceV1BTree) Report(query types.ReportQuery) (report types.Report, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+	return reportOf(query, p.sessions, p.store, p.ledger, p.lotCapacity, p.currency, p.clock.Now())
+}
+
+func (p *ParkingServiceV1BTree) OpenParkingArea(layout types.LotLayout) (err error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if parkingCap < 1 {
-		err = fmt.Errorf("parking cap must be at least 1")
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	if err = p.openArea(layout); err != nil {
 		return
 	}
 
+	return p.journal.record(opOpen, p.clock.Now(), openEntry{Capacity: layout.Capacity(), Layout: layout})
+}
+
+func (p *ParkingServiceV1BTree) openArea(layout types.LotLayout) (err error) {
+	slots, errLayout := slotsOf(layout)
+	if errLayout != nil {
+		return errLayout
+	}
+
 	if p.lotCapacity > 0 {
-		err = fmt.Errorf("failed, already initalize the parking lot capacity")
+		err = contract.ErrAlreadyInitialized
 		return
 	}
 
-	p.lotCapacity = parkingCap
-	p.store = make([]*types.Car, parkingCap)
-	p.hotspot = btree.NewOrderedG[int](32)
+	p.lotCapacity = len(slots)
+	p.store = make([]*types.Car, len(slots))
+	p.slots = slots
 	p.history = make(map[string]int)
+	p.reserved = make(map[string]int)
+	p.colors = make(map[string]*btree.BTreeG[int])
+	p.rebuildHotspot()
+	return
+}
 
-	for i := 0; i < parkingCap; i++ {
-		p.hotspot.ReplaceOrInsert(i)
+func (p *ParkingServiceV1BTree) ResizeParkingArea(layout types.LotLayout, drain bool) (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	if err = p.resize(layout, drain, at); err != nil {
+		return
+	}
+
+	return p.journal.record(opResize, at, resizeEntry{Layout: layout, Drain: drain})
+}
+
+func (p *ParkingServiceV1BTree) resize(layout types.LotLayout, drain bool, at time.Time) (err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	// lapsed reservation no longer hold the slot the layout remove
+	p.settleReservations(at)
+
+	newStore, newSlots, errResize := resizeSlots(layout, drain, p.store, p.slots)
+	if errResize != nil {
+		return errResize
+	}
+
+	// car keep its slot index, so history is still valid and only the free slot change
+	p.lotCapacity = layout.Capacity()
+	p.store = newStore
+	p.slots = newSlots
+	p.rebuildHotspot()
+	return
+}
+
+// rebuildHotspot index every free slot that can take new car
+func (p *ParkingServiceV1BTree) rebuildHotspot() {
+	p.hotspot = make(map[string]*btree.BTreeG[int])
+	for i, car := range p.store {
+		if car == nil && p.slots[i].Available() {
+			p.free(i)
+		}
+	}
+}
+
+// free put the slot index back into the free tree of its class
+func (p *ParkingServiceV1BTree) free(index int) {
+	class := p.slots[index].Class
+	tree, ok := p.hotspot[class]
+	if !ok {
+		tree = btree.NewOrderedG[int](32)
+		p.hotspot[class] = tree
+	}
+	tree.ReplaceOrInsert(index)
+}
+
+// nearest return the lowest free slot index the vehicle class fit on that accept take
+func (p *ParkingServiceV1BTree) nearest(class string, accept func(slot types.Slot) bool) (index int, found bool) {
+	for _, slotClass := range types.SlotClassFor(class) {
+		tree, ok := p.hotspot[slotClass]
+		if !ok {
+			continue
+		}
+
+		tree.Ascend(func(i int) bool {
+			if found && i >= index {
+				return false
+			}
+			if accept(p.slots[i]) {
+				index, found = i, true
+				return false
+			}
+			return true
+		})
+	}
+	return
+}
+
+func (p *ParkingServiceV1BTree) DisableSlot(number int, reason string, force bool) (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	if err = p.disable(number, reason, force, at); err != nil {
+		return
+	}
+
+	return p.journal.record(opDisable, at, slotEntry{Number: number, Reason: reason, Force: force})
+}
+
+func (p *ParkingServiceV1BTree) disable(number int, reason string, force bool, at time.Time) (err error) {
+	index, errSlot := maintainedSlot(number, p.lotCapacity)
+	if errSlot != nil {
+		return errSlot
+	}
+
+	p.settleReservations(at)
+
+	if car := p.store[index]; car != nil && !force {
+		err = fmt.Errorf("%w: %s on slot %d, force to disable it anyway", contract.ErrSlotOccupied, car.PoliceNumber, number)
+		return
+	}
+
+	if reserved := p.slots[index].Reserved; reserved != nil && !force {
+		err = fmt.Errorf("%w: slot %d reserved for %s, force to disable it anyway", contract.ErrSlotOccupied, number, reserved.PoliceNumber)
+		return
+	}
+
+	// forced disable drop the reservation, replaying the logged disable drop it again
+	if reserved := p.slots[index].Reserved; reserved != nil {
+		delete(p.reserved, plate.Key(reserved.PoliceNumber))
+		p.slots[index].Reserved = nil
+	}
+
+	p.slots[index].Maintenance = &types.Maintenance{Reason: reason, Since: at}
+
+	// free slot no longer allocatable, already disabled one is not in the tree
+	if tree, ok := p.hotspot[p.slots[index].Class]; ok && p.store[index] == nil {
+		tree.Delete(index)
+	}
+	return
+}
+
+func (p *ParkingServiceV1BTree) EnableSlot(number int) (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	if err = p.enable(number); err != nil {
+		return
+	}
+
+	return p.journal.record(opEnable, p.clock.Now(), slotEntry{Number: number})
+}
+
+func (p *ParkingServiceV1BTree) enable(number int) (err error) {
+	index, errSlot := maintainedSlot(number, p.lotCapacity)
+	if errSlot != nil {
+		return errSlot
+	}
+
+	p.slots[index].Maintenance = nil
+
+	if p.store[index] == nil && p.slots[index].Available() {
+		p.free(index)
 	}
 	return
 }
@@ -86,78 +518,472 @@ func (p *ParkingServiceV1BTree) EnterArea(request types.CarDTO) (areaId int, err
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if len(request.PoliceNumber) == 0 {
-		err = fmt.Errorf("failed, police number is empty")
+	if err = p.journal.ready(); err != nil {
 		return
 	}
 
-	if _, exist := p.history[request.PoliceNumber]; exist {
-		err = fmt.Errorf("failed, car already parked")
+	if err = validVehicle(request); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	if areaId, err = p.enter(request, at); err != nil {
+		return
+	}
+
+	if err = p.journal.record(opEnter, at, enterEntry{Request: request}); err != nil {
+		return 0, err
+	}
+	return
+}
+
+func (p *ParkingServiceV1BTree) enter(request types.CarDTO, at time.Time) (areaId int, err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
 		return
 	}
 
-	// validate if  car number not already exist on the parking area
-	openArea, found := p.hotspot.Min()
-	if !found {
-		err = fmt.Errorf("failed, parking lot is full")
+	class, errClass := vehicleClassOf(request)
+	if errClass != nil {
+		return 0, errClass
+	}
+
+	p.settleReservations(at)
+
+	key := plate.Key(request.PoliceNumber)
+	if _, exist := p.history[key]; exist {
+		err = fmt.Errorf("%w: %s", contract.ErrAlreadyParked, request.GetPoliceNumber())
 		return
 	}
+
+	// car with reservation take its slot, unless walk-in car still park on it
+	openArea, reserved := p.reserved[key]
+	if reserved {
+		if !types.Fits(p.slots[openArea].Class, class) {
+			err = fmt.Errorf("%w: %s does not fit reserved %s slot", contract.ErrInvalidRequest, class, p.slots[openArea].Class)
+			return
+		}
+		p.slots[openArea].Reserved = nil
+		delete(p.reserved, key)
+		reserved = p.store[openArea] == nil && p.slots[openArea].Available()
+	}
+	if !reserved {
+		found := false
+		if openArea, found = p.nearest(class, walkIn(at)); !found {
+			err = fmt.Errorf("%w: no free slot for %s", contract.ErrLotFull, class)
+			return
+		}
+	}
+	p.hotspot[p.slots[openArea].Class].Delete(openArea)
 	areaId = openArea + 1
 
 	// for compatible with v1 contract
 	in := &types.Car{
 		Id:           request.RequestId,
 		AreaNumber:   areaId,
+		SlotLabel:    p.slots[openArea].Label,
+		Color:        request.GetColor(),
+		Make:         strings.TrimSpace(request.Make),
+		Model:        strings.TrimSpace(request.Model),
+		Photo:        strings.TrimSpace(request.Photo),
+		Class:        class,
 		PoliceNumber: request.GetPoliceNumber(),
-		ParkingAt:    time.Now(),
+		ParkingAt:    at,
 		ExitAt:       nil,
 	}
 
-	p.hotspot.Delete(openArea)
 	p.store[openArea] = in
-	p.history[request.PoliceNumber] = openArea
+	p.history[key] = openArea
+	p.indexColor(openArea)
+
+	return
+}
+
+func (p *ParkingServiceV1BTree) Reserve(request types.ReservationDTO) (reservation types.Reservation, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	if reservation, err = p.reserve(request, at, nil); err != nil {
+		return
+	}
+
+	if err = p.journal.record(opReserve, at, reserveEntry{Request: request, Reserved: reservation}); err != nil {
+		return types.Reservation{}, err
+	}
+	return
+}
+
+// reserve hold slot for the plate, pinned is the already made reservation when replaying the log
+func (p *ParkingServiceV1BTree) reserve(request types.ReservationDTO, at time.Time, pinned *types.Reservation) (reservation types.Reservation, err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	class, errCheck := checkReservation(request, at, p.grace, pinned != nil)
+	if errCheck != nil {
+		return reservation, errCheck
+	}
+
+	p.settleReservations(at)
+	key := plate.Key(request.PoliceNumber)
+	if _, exist := p.reserved[key]; exist {
+		err = fmt.Errorf("%w: %s", contract.ErrAlreadyReserved, request.GetPoliceNumber())
+		return
+	}
+
+	if pinned != nil {
+		request.Slot = pinned.Slot
+	}
+
+	index := -1
+	if request.Slot > 0 {
+		if index, err = reservableSlot(p.store, p.slots, p.lotCapacity, request.Slot, class); err != nil {
+			return
+		}
+	} else {
+		found := false
+		if index, found = p.nearest(class, unreserved); !found {
+			err = fmt.Errorf("%w: no free slot for %s", contract.ErrLotFull, class)
+			return
+		}
+	}
+
+	// the slot stay in the free tree, walk-in skip it only within the reservation window
+	reservation = newReservation(request, class, index+1, p.grace, pinned)
+	held := reservation
+	p.slots[index].Reserved = &held
+	p.reserved[key] = index
+	return
+}
+
+func (p *ParkingServiceV1BTree) CancelReservation(policeNumber string) (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	if err = p.cancel(policeNumber, at); err != nil {
+		return
+	}
+
+	return p.journal.record(opCancel, at, cancelEntry{PoliceNumber: policeNumber})
+}
+
+func (p *ParkingServiceV1BTree) cancel(policeNumber string, at time.Time) (err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	p.settleReservations(at)
+	key := plate.Key(policeNumber)
+	index, exist := p.reserved[key]
+	if !exist {
+		err = fmt.Errorf("%w: %s", contract.ErrNoReservation, policeNumber)
+		return
+	}
 
+	p.unreserve(key, index)
 	return
 }
 
+// settleReservations release the reservation whose car did not arrive within the grace period
+// or whose window ended, and move the started one whose slot is still taken by walk-in car
+// to the nearest free slot
+func (p *ParkingServiceV1BTree) settleReservations(at time.Time) {
+	// slot order keep the relocation the same when the log is replayed
+	indexes := make([]int, 0, len(p.reserved))
+	for _, index := range p.reserved {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		reserved := p.slots[index].Reserved
+		key := plate.Key(reserved.PoliceNumber)
+		switch {
+		case !reserved.Active(at):
+			p.unreserve(key, index)
+		case reserved.Holds(at) && p.store[index] != nil:
+			if moved, found := p.nearest(reserved.Class, unreserved); found {
+				p.slots[index].Reserved = nil
+				p.slots[moved].Reserved = relocated(reserved, moved)
+				p.reserved[key] = moved
+			}
+		}
+	}
+}
+
+// unreserve drop the reservation, its free slot is already in the free tree
+func (p *ParkingServiceV1BTree) unreserve(key string, index int) {
+	delete(p.reserved, key)
+	p.slots[index].Reserved = nil
+}
+
 func (p *ParkingServiceV1BTree) LeaveArea(req types.CarDTO) (exitedCar types.Car, err error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if req.Hours < 1 {
-		err = fmt.Errorf("failed, parking must be at least 1 hour")
+	if err = p.journal.ready(); err != nil {
 		return
 	}
 
-	parkingSpot, exists := p.history[req.PoliceNumber]
+	at := p.clock.Now()
+	if exitedCar, err = p.leave(req, at, nil); err != nil {
+		return
+	}
+
+	if err = p.journal.record(opLeave, at, leaveEntry{Request: req, Exited: exitedCar}); err != nil {
+		return types.Car{}, err
+	}
+	return
+}
+
+// leave free the car slot, priced is the already charged car when replaying the log
+func (p *ParkingServiceV1BTree) leave(req types.CarDTO, at time.Time, priced *types.Car) (exitedCar types.Car, err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	if req.Hours < 0 {
+		err = contract.ErrInvalidDuration
+		return
+	}
+
+	// the override is a simulation aid, a live lot bill the elapsed time
+	if req.Hours > 0 && priced == nil && !p.simulateHours {
+		err = fmt.Errorf("%w: hours override is disabled on this server", contract.ErrInvalidRequest)
+		return
+	}
+
+	method, errMethod := methodOf(req)
+	if errMethod != nil {
+		return types.Car{}, errMethod
+	}
+
+	key := plate.Key(req.PoliceNumber)
+	parkingSpot, exists := p.history[key]
 	if !exists {
-		err = fmt.Errorf("this car %s not exist on parking area", req.PoliceNumber)
+		err = fmt.Errorf("%w: %s", contract.ErrCarNotFound, req.GetPoliceNumber())
 		return
 	}
 
 	// get the car data
 	car := p.store[parkingSpot]
 
+	// price before the slot is freed so refused amount leave the lot untouched, replay keep
+	// the charged price as the tariff may have changed since
+	var charged types.Car
+	if priced == nil {
+		// elapsed since parked, hours only override the billed duration for simulation
+		end := at
+		if req.Hours > 0 {
+			end = car.ParkingAt.Add(time.Duration(req.Hours) * time.Hour)
+		}
+		cost, errCost := priceIn(p.tariff.Cost(car.ParkingAt, end), p.currency)
+		if errCost != nil {
+			return types.Car{}, errCost
+		}
+		charged.ExitAt = &at
+		charged.Discount, charged.DiscountReason = p.discountOf(key, cost, at)
+		charged.Cost = cost.Sub(charged.Discount)
+	} else if charged, err = repriced(*priced, p.currency); err != nil {
+		return types.Car{}, err
+	}
+
 	// free the history mem
-	delete(p.history, req.PoliceNumber)
+	delete(p.history, key)
+	p.unindexColor(parkingSpot)
 	p.store[parkingSpot] = nil
-	p.hotspot.ReplaceOrInsert(parkingSpot)
+	switch {
+	case p.slots[parkingSpot].Draining:
+		p.store, p.slots = trimDrained(p.store, p.slots, p.lotCapacity)
+	case p.slots[parkingSpot].Available():
+		p.free(parkingSpot)
+	}
 
-	start := car.ParkingAt
-	end := start.Add(time.Duration(req.Hours) * time.Hour)
-	car.ExitAt = &end
-	car.Cost = p.calculateCost(req.Hours)
+	car.ExitAt = charged.ExitAt
+	car.Cost = charged.Cost
+	car.Discount = charged.Discount
+	car.DiscountReason = charged.DiscountReason
+	car.PaymentId, car.Method = uuid.NewString(), method
+
+	// log written before the ledger has no payment id, it keep the fresh one
+	if priced != nil && priced.PaymentId != "" {
+		car.PaymentId, car.Method = priced.PaymentId, priced.Method
+	}
 
 	// pay the tx cost
-	p.pay(req.GetPoliceNumber())
+	p.pay(key)
+
+	// collect the cost into the ledger
+	p.ledger = append(p.ledger, paymentEntry(*car, at))
 
-	// add revenue
-	p.revenue = p.revenue + car.Cost
+	// keep the completed session for history and receipt
+	p.sessions = append(p.sessions, types.SessionOf(*car))
 
 	exitedCar = *car
 	return
 }
 
+// discountOf price the member or loyalty discount of the plate key, before its visit is counted
+func (p *ParkingServiceV1BTree) discountOf(key string, cost money.Money, at time.Time) (discount money.Money, reason string) {
+	var member *types.Member
+	if current, ok := p.members[key]; ok {
+		member = &current
+	}
+	return discountOf(cost, member, p.loyalty, p.tx[key], at)
+}
+
+func (p *ParkingServiceV1BTree) AddMember(request types.MemberDTO) (member types.Member, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	if member, err = p.addMember(request, at); err != nil {
+		return
+	}
+
+	if err = p.journal.record(opMember, at, memberEntry{Request: request}); err != nil {
+		return types.Member{}, err
+	}
+	return
+}
+
+// addMember register or renew the monthly pass of the plate
+func (p *ParkingServiceV1BTree) addMember(request types.MemberDTO, at time.Time) (member types.Member, err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	key := plate.Key(request.PoliceNumber)
+	var current *types.Member
+	if existing, ok := p.members[key]; ok {
+		current = &existing
+	}
+
+	if member, err = renewMember(request, current, at); err != nil {
+		return
+	}
+
+	p.members[key] = member
+	return
+}
+
+func (p *ParkingServiceV1BTree) RemoveMember(policeNumber string) (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	if err = p.removeMember(policeNumber); err != nil {
+		return
+	}
+
+	return p.journal.record(opRemoveMember, p.clock.Now(), cancelEntry{PoliceNumber: policeNumber})
+}
+
+func (p *ParkingServiceV1BTree) removeMember(policeNumber string) (err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	key := plate.Key(policeNumber)
+	if _, ok := p.members[key]; !ok {
+		err = fmt.Errorf("%w: %s", contract.ErrMemberNotFound, types.CarDTO{PoliceNumber: policeNumber}.GetPoliceNumber())
+		return
+	}
+
+	delete(p.members, key)
+	return
+}
+
+func (p *ParkingServiceV1BTree) Members() (members []types.Member, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+	return sortedMembers(p.members), nil
+}
+
+func (p *ParkingServiceV1BTree) Refund(request types.RefundDTO) (payment types.Payment, err error) {
+	return p.adjustPayment(types.EntryRefund, opRefund, request)
+}
+
+func (p *ParkingServiceV1BTree) Void(request types.RefundDTO) (payment types.Payment, err error) {
+	return p.adjustPayment(types.EntryVoid, opVoid, request)
+}
+
+// adjustPayment record the refund or void of the payment and return the payment after it
+func (p *ParkingServiceV1BTree) adjustPayment(kind, op string, request types.RefundDTO) (payment types.Payment, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err = p.journal.ready(); err != nil {
+		return
+	}
+
+	at := p.clock.Now()
+	entry, errAdjust := p.adjust(kind, request, at, nil)
+	if errAdjust != nil {
+		return payment, errAdjust
+	}
+
+	if err = p.journal.record(op, at, adjustEntry{Request: request, Entry: entry}); err != nil {
+		return
+	}
+
+	payment, _ = p.ledger.Payment(entry.PaymentId)
+	return payment, nil
+}
+
+// adjust append the entry reversing the payment, pinned is the entry already in the log
+func (p *ParkingServiceV1BTree) adjust(kind string, request types.RefundDTO, at time.Time, pinned *types.LedgerEntry) (entry types.LedgerEntry, err error) {
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+
+	if entry, err = adjustment(p.ledger, kind, request, at, pinned); err != nil {
+		return
+	}
+
+	p.ledger = append(p.ledger, entry)
+	return
+}
+
+func (p *ParkingServiceV1BTree) Payments(query types.PaymentQuery) (report types.PaymentReport, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lotCapacity == 0 {
+		err = contract.ErrNotInitialized
+		return
+	}
+	return p.ledger.ReportOf(query, p.currency), nil
+}
+
 func (p *ParkingServiceV1BTree) pay(policeNumber string) {
 	// on existing tx book history
 	if val, ok := p.tx[policeNumber]; ok {
@@ -169,15 +995,3 @@ func (p *ParkingServiceV1BTree) pay(policeNumber string) {
 	// new member
 	p.tx[policeNumber] = 1
 }
-
-func (p *ParkingServiceV1BTree) calculateCost(hours int) float64 {
-	const baseCost = 10
-	const baseCostMinHours = 2
-	if hours <= baseCostMinHours {
-		return baseCost
-	}
-
-	extraHours := hours - 2
-
-	return float64(baseCost + (extraHours * baseCost))
-}
@@ -8,4 +8,11 @@ import (
 var AppConfig = types.AppConfig{
 	ConnLifetime: 10 * time.Second,
 	AppVersion:   "v0.1.0",
+	DataDir:      "data",
+
+	ReservationGrace: 15 * time.Minute,
+	Currency:         types.DefaultCurrency,
+
+	CompactInterval: 1 * time.Minute,
+	ShutdownTimeout: 5 * time.Second,
 }
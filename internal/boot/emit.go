@@ -1,16 +1,22 @@
 package boot
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
+	"github.com/khafidprayoga/parking-app/contract"
 	"github.com/khafidprayoga/parking-app/internal/server"
 	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/khafidprayoga/parking-app/internal/wire"
+	"io"
 	"log"
 	"net"
 	"time"
 )
 
-func emit(conn net.Conn, service *server.ParkingAppServer) {
+// emit serve every request sent over the connection in order until the client close it,
+// stay idle longer than the conn lifetime, or the server is shutting down
+func emit(conn net.Conn, service *server.ParkingAppServer, closing <-chan struct{}) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("panic recovered in conn handler: %v", r)
@@ -18,19 +24,69 @@ func emit(conn net.Conn, service *server.ParkingAppServer) {
 		conn.Close()
 	}()
 
-	buf := make([]byte, 1024)
-	n, err := conn.Read(buf)
-	if err != nil {
-		log.Printf("error reading from connection: %v", err)
-		return
-	}
+	for {
+		// waiting for the next request
+		if errDeadline := conn.SetReadDeadline(time.Now().Add(AppConfig.ConnLifetime)); errDeadline != nil {
+			log.Printf("error setting deadline on connection: %v", errDeadline)
+			return
+		}
+
+		// checked after the deadline is set, shutdown expire it right after closing
+		select {
+		case <-closing:
+			return
+		default:
+		}
+
+		data := types.Socket{}
+		if err := wire.ReadFrame(conn, &data); err != nil {
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return
+			}
+
+			log.Printf("error reading from connection: %v", err)
+			if errors.Is(err, wire.ErrFrameTooLarge) {
+				// tell the client why, the rest of the oversized frame is never read
+				_ = wire.WriteFrame(conn, types.SocketServerResponse{
+					Status:  types.SocketCallError,
+					Code:    types.ErrCodeFrameTooLarge,
+					Message: fmt.Sprintf("request rejected, %v", err),
+				})
+			}
+			return
+		}
+
+		response := handle(data, service)
 
-	data := types.Socket{}
-	if err := json.Unmarshal(buf[:n], &data); err != nil {
-		log.Printf("error unmarshalling from connection: %v", err)
-		return
+		if errDeadline := conn.SetWriteDeadline(time.Now().Add(AppConfig.ConnLifetime)); errDeadline != nil {
+			log.Printf("error setting deadline on connection: %v", errDeadline)
+			return
+		}
+
+		errWrite := wire.WriteFrame(conn, response)
+		if errors.Is(errWrite, wire.ErrFrameTooLarge) {
+			log.Printf("error writing response of request %v: %v", response.XRequestId, errWrite)
+			errWrite = wire.WriteFrame(conn, types.SocketServerResponse{
+				XRequestId: response.XRequestId,
+				Status:     types.SocketCallError,
+				Code:       types.ErrCodeFrameTooLarge,
+				Message:    fmt.Sprintf("response of %s is not sent, %v", data.Command, errWrite),
+			})
+		}
+
+		if errWrite != nil {
+			log.Printf("error writing to connection: %v", errWrite)
+			return
+		}
 	}
+}
 
+func handle(data types.Socket, service *server.ParkingAppServer) types.SocketServerResponse {
 	id, e := uuid.Parse(data.XRequestId)
 
 	if e != nil {
@@ -38,28 +94,32 @@ func emit(conn net.Conn, service *server.ParkingAppServer) {
 		id = uuid.New()
 	}
 
+	// client match the response by its own request id
+	if data.XRequestId == "" {
+		data.XRequestId = id.String()
+	}
+
 	log.Printf(
 		"Handling Request with Id: %v Command: %v At: %v \n",
 		id.String(),
 		data.Command,
 		time.Now().Format(time.RFC3339),
 	)
-	resMsg, errProcess := service.HandleIncomingMsg(data)
+	resMsg, resData, errProcess := service.HandleIncomingMsg(data)
 
 	response := types.SocketServerResponse{
-		Status:  types.SocketCallSuccess,
-		Message: resMsg,
+		XRequestId: data.XRequestId,
+		Status:     types.SocketCallSuccess,
+		Message:    resMsg,
+		Data:       resData,
 	}
 
 	if errProcess != nil {
 		response.Status = types.SocketCallError
+		response.Code = contract.CodeOf(errProcess)
 		response.Message = errProcess.Error()
+		response.Data = nil
 	}
 
-	resB, errM := json.Marshal(response)
-	if errM != nil {
-		log.Printf("error reading from connection: %v", err)
-		return
-	}
-	conn.Write(resB)
+	return response
 }
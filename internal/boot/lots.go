@@ -0,0 +1,175 @@
+package boot
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+// lotBackend is the backend of single named lot and the store it persist into
+type lotBackend struct {
+	uc persistentUseCase
+	db store.Store
+}
+
+// lotPool own every lot backend of the server, so they are compacted and closed together.
+// Default lot persist on the data dir itself, named lot on data dir/lots/<name>
+type lotPool struct {
+	mu sync.Mutex
+
+	version types.BackendVersion
+	opts    []backend.Option
+	dataDir string
+	lots    map[string]*lotBackend
+}
+
+func newLotPool(version types.BackendVersion, dataDir string, opts []backend.Option) *lotPool {
+	return &lotPool{
+		version: version,
+		opts:    opts,
+		dataDir: dataDir,
+		lots:    make(map[string]*lotBackend),
+	}
+}
+
+// restore bring up the default lot and every lot persisted on the data dir
+func (p *lotPool) restore() (lots map[string]contract.IParkingUseCase, err error) {
+	names := []string{types.DefaultLot}
+	if p.dataDir != "" {
+		dirs, errRead := os.ReadDir(filepath.Join(p.dataDir, "lots"))
+		if errRead != nil && !os.IsNotExist(errRead) {
+			return nil, fmt.Errorf("failed to list persisted lots: %v", errRead)
+		}
+
+		for _, dir := range dirs {
+			if dir.IsDir() && dir.Name() != types.DefaultLot {
+				names = append(names, dir.Name())
+			}
+		}
+	}
+
+	lots = make(map[string]contract.IParkingUseCase)
+	for _, name := range names {
+		uc, errOpen := p.open(name)
+		if errOpen != nil {
+			return nil, errOpen
+		}
+		lots[name] = uc
+	}
+	return
+}
+
+// open create and restore the backend of the lot
+func (p *lotPool) open(name string) (uc contract.IParkingUseCase, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if lot, ok := p.lots[name]; ok {
+		return lot.uc, nil
+	}
+
+	lot := &lotBackend{}
+	opts := append([]backend.Option(nil), p.opts...)
+	if p.dataDir != "" {
+		dir := p.dataDir
+		if name != types.DefaultLot {
+			dir = filepath.Join(p.dataDir, "lots", name)
+		}
+
+		fileStore, errOpenStore := store.NewFileStore(dir)
+		if errOpenStore != nil {
+			return nil, fmt.Errorf("failed to open store of lot %s: %v", name, errOpenStore)
+		}
+		lot.db = fileStore
+		opts = append(opts, backend.WithStore(fileStore))
+	}
+
+	switch p.version {
+	case types.V1BTree:
+		lot.uc = backend.NewParkingServiceBTree(opts...)
+	default:
+		lot.uc = backend.NewParkingService(opts...)
+	}
+
+	if errRestore := lot.uc.Restore(); errRestore != nil {
+		if lot.db != nil {
+			_ = lot.db.Close()
+		}
+		return nil, fmt.Errorf("failed to restore lot %s: %v", name, errRestore)
+	}
+
+	p.lots[name] = lot
+	return lot.uc, nil
+}
+
+// discard close the lot and remove its store, the lot is not restored on next boot
+func (p *lotPool) discard(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lot, ok := p.lots[name]
+	if !ok || name == types.DefaultLot {
+		return
+	}
+	delete(p.lots, name)
+
+	if lot.db == nil {
+		return
+	}
+	if errCloseDb := lot.db.Close(); errCloseDb != nil {
+		log.Printf("error closing store of lot %s: %v", name, errCloseDb)
+	}
+	if errRemove := os.RemoveAll(filepath.Join(p.dataDir, "lots", name)); errRemove != nil {
+		log.Printf("error removing store of lot %s: %v", name, errRemove)
+	}
+}
+
+// each call fn on every lot in name order
+func (p *lotPool) each(fn func(name string, lot *lotBackend)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	names := make([]string, 0, len(p.lots))
+	for name := range p.lots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fn(name, p.lots[name])
+	}
+}
+
+// compact fold the write-ahead log of every persisted lot
+func (p *lotPool) compact() {
+	p.each(func(name string, lot *lotBackend) {
+		if lot.db == nil {
+			return
+		}
+		if errCompact := lot.uc.Compact(); errCompact != nil {
+			log.Printf("error compacting parking state of lot %s: %v", name, errCompact)
+		}
+	})
+}
+
+// close compact and close the store of every lot, every acknowledged request is
+// already in the log, compaction only speed up next boot
+func (p *lotPool) close() {
+	p.compact()
+	p.each(func(name string, lot *lotBackend) {
+		if lot.db == nil {
+			return
+		}
+		if errCloseDb := lot.db.Close(); errCloseDb != nil {
+			log.Printf("error closing store of lot %s: %v", name, errCloseDb)
+		}
+	})
+}
@@ -1,21 +1,33 @@
 package boot
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"github.com/khafidprayoga/parking-app/contract"
 	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/tariff"
 	"github.com/khafidprayoga/parking-app/internal/types"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/khafidprayoga/parking-app/internal/server"
 )
 
+// persistentUseCase is backend that able to pick up its persisted state on boot
+// and fold its write-ahead log into snapshot
+type persistentUseCase interface {
+	contract.IParkingUseCase
+	Restore() error
+	Compact() error
+}
+
 func StartApp(version types.BackendVersion) {
 	// init socket
 	listener, err := net.Listen("tcp", ":8080")
@@ -25,22 +37,86 @@ func StartApp(version types.BackendVersion) {
 
 	log.Printf("Parking App Server %s%s is listening on port :8080\n", AppConfig.AppVersion, version)
 
-	var uc contract.IParkingUseCase
-	switch version {
-	case types.V1:
-		uc = backend.NewParkingService()
-	case types.V1BTree:
-		uc = backend.NewParkingServiceBTree()
-	default:
-		uc = backend.NewParkingService()
+	opts := []backend.Option{
+		backend.WithReservationGrace(AppConfig.ReservationGrace),
+		backend.WithCurrency(AppConfig.Currency),
+	}
+	if AppConfig.SimulateHours {
+		opts = append(opts, backend.WithSimulatedHours())
+	}
+
+	if AppConfig.TariffFile != "" {
+		rule, errTariff := tariff.LoadFile(AppConfig.TariffFile)
+		if errTariff != nil {
+			log.Fatalf("error loading tariff with reason %v", errTariff)
+		}
+
+		log.Printf("Parking App Server pricing with tariff %s\n", AppConfig.TariffFile)
+		opts = append(opts, backend.WithTariff(rule), backend.WithLoyalty(rule.Loyalty()))
 	}
 
-	service := server.CreateAppServer(uc)
+	if AppConfig.DataDir != "" {
+		log.Printf("Parking App Server persisting state at %s\n", AppConfig.DataDir)
+	}
+
+	// every named lot is own backend instance with its own store
+	pool := newLotPool(version, AppConfig.DataDir, opts)
+	lots, errRestore := pool.restore()
+	if errRestore != nil {
+		log.Fatalf("error restoring parking state with reason %v", errRestore)
+	}
+
+	service := server.CreateMultiLotServer(lots, pool.open, pool.discard)
+	log.Printf("Parking App Server hosting lot %s\n", strings.Join(service.Lots(), ", "))
 
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+
+	// keep the log short, replay on boot only need the entries after last snapshot
+	stopCompact := make(chan struct{})
+	go func() {
+		if AppConfig.DataDir == "" || AppConfig.CompactInterval <= 0 {
+			return
+		}
+
+		ticker := time.NewTicker(AppConfig.CompactInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				pool.compact()
+			case <-stopCompact:
+				return
+			}
+		}
+	}()
+
+	// rest api for client that does not speak the socket protocol
+	var httpServer *http.Server
+	if AppConfig.HTTPAddr != "" {
+		httpServer = &http.Server{
+			Addr:              AppConfig.HTTPAddr,
+			Handler:           service.HTTPHandler(),
+			ReadHeaderTimeout: AppConfig.ConnLifetime,
+			IdleTimeout:       AppConfig.ConnLifetime,
+		}
+
+		go func() {
+			log.Printf("Parking App HTTP API is listening on %s\n", AppConfig.HTTPAddr)
+			if errServe := httpServer.ListenAndServe(); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
+				log.Fatalf("error listening http api on %s with reason %v", AppConfig.HTTPAddr, errServe)
+			}
+		}()
+	}
 
 	// running server main thread for backend service in background
+	var (
+		inFlight = sync.WaitGroup{}
+		closing  = make(chan struct{})
+		connMu   sync.Mutex
+		openConn = make(map[net.Conn]struct{})
+	)
 	go func() {
 		for {
 			// handling incoming request
@@ -57,16 +133,21 @@ func StartApp(version types.BackendVersion) {
 				continue
 			}
 
-			// set req-res timeout
-			errSetLifetime := conn.SetDeadline(time.Now().Add(AppConfig.ConnLifetime))
-			if errSetLifetime != nil {
-				errMsg := errors.Unwrap(errSetLifetime)
-				log.Printf("error setting deadline on connection: %v", errMsg)
-				continue
-			}
+			connMu.Lock()
+			openConn[conn] = struct{}{}
+			connMu.Unlock()
 
 			// emit data to service
-			go emit(conn, service)
+			inFlight.Add(1)
+			go func() {
+				defer func() {
+					connMu.Lock()
+					delete(openConn, conn)
+					connMu.Unlock()
+					inFlight.Done()
+				}()
+				emit(conn, service, closing)
+			}()
 		}
 	}()
 
@@ -77,13 +158,40 @@ func StartApp(version types.BackendVersion) {
 		log.Println(errCloseTcp)
 	}
 
-	log.Println("shutting down app in a few seconds")
-	ticker := time.NewTicker(1 * time.Second)
+	log.Println("shutting down app, waiting in flight request to finish")
 
-	for x := 0; x < 3; x++ {
-		<-ticker.C
-		fmt.Printf(".")
+	// wake up connection idle on waiting next request, the busy one finish its current request first
+	close(closing)
+	connMu.Lock()
+	for conn := range openConn {
+		_ = conn.SetReadDeadline(time.Now())
 	}
-	ticker.Stop()
+	connMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		if httpServer != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), AppConfig.ShutdownTimeout)
+			defer cancel()
+
+			if errShutdown := httpServer.Shutdown(ctx); errShutdown != nil {
+				log.Printf("error shutting down http api: %v", errShutdown)
+			}
+		}
+
+		inFlight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(AppConfig.ShutdownTimeout):
+		log.Println("in flight request is not finished in time, they are not acknowledged")
+	}
+	close(stopCompact)
+
+	pool.close()
+
+	log.Println("server stopped")
 	os.Exit(0)
 }
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/khafidprayoga/parking-app/internal/wire"
+)
+
+// Client keep single long-lived connection to the parking app server,
+// many request can be in flight at once and their responses matched back by XRequestId
+type Client struct {
+	conn net.Conn
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan types.SocketServerResponse
+	readErr error
+	done    chan struct{}
+}
+
+// Call is single in flight request
+type Call struct {
+	Request  types.Socket
+	response chan types.SocketServerResponse
+}
+
+func Dial(addr string) (*Client, error) {
+	conn, errDial := net.Dial("tcp", addr)
+	if errDial != nil {
+		return nil, errDial
+	}
+
+	c := &Client{
+		conn:    conn,
+		pending: make(map[string]chan types.SocketServerResponse),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+
+	return c, nil
+}
+
+// Go send the request without waiting for its response, empty XRequestId is generated
+func (c *Client) Go(req types.Socket) (call *Call, err error) {
+	if req.XRequestId == "" {
+		req.XRequestId = uuid.NewString()
+	}
+
+	call = &Call{
+		Request:  req,
+		response: make(chan types.SocketServerResponse, 1),
+	}
+
+	c.mu.Lock()
+	if c.readErr != nil {
+		errRead := c.readErr
+		c.mu.Unlock()
+		return nil, fmt.Errorf("connection is closed: %v", errRead)
+	}
+	c.pending[call.Request.XRequestId] = call.response
+	c.mu.Unlock()
+
+	c.writeMu.Lock()
+	errSend := wire.WriteFrame(c.conn, call.Request)
+	c.writeMu.Unlock()
+
+	if errSend != nil {
+		c.mu.Lock()
+		delete(c.pending, call.Request.XRequestId)
+		c.mu.Unlock()
+
+		return nil, fmt.Errorf("cannot send request: %v", errSend)
+	}
+	return call, nil
+}
+
+// Wait block until the response of call arrive or the connection is broken
+func (c *Client) Wait(call *Call) (res types.SocketServerResponse, err error) {
+	select {
+	case res = <-call.response:
+		return res, nil
+	case <-c.done:
+		// response may arrive right before the connection closed
+		select {
+		case res = <-call.response:
+			return res, nil
+		default:
+		}
+
+		c.mu.Lock()
+		errRead := c.readErr
+		c.mu.Unlock()
+		return res, fmt.Errorf("cannot read response: %v", errRead)
+	}
+}
+
+// Do send single request and wait for its response
+func (c *Client) Do(command string, data any) (res types.SocketServerResponse, err error) {
+	call, errSend := c.Go(types.Socket{
+		Command: command,
+		Data:    data,
+	})
+	if errSend != nil {
+		return res, errSend
+	}
+	return c.Wait(call)
+}
+
+// Pipeline send the request list over the connection keeping at most window request in flight,
+// onResponse is called in the request order
+func (c *Client) Pipeline(reqList []types.Socket, window int, onResponse func(req types.Socket, res types.SocketServerResponse)) error {
+	if window < 1 {
+		window = 1
+	}
+
+	inFlight := make([]*Call, 0, window)
+	for _, req := range reqList {
+		if len(inFlight) == window {
+			oldest := inFlight[0]
+			inFlight = inFlight[1:]
+
+			res, errWait := c.Wait(oldest)
+			if errWait != nil {
+				return errWait
+			}
+			onResponse(oldest.Request, res)
+		}
+
+		call, errSend := c.Go(req)
+		if errSend != nil {
+			return errSend
+		}
+		inFlight = append(inFlight, call)
+	}
+
+	for _, call := range inFlight {
+		res, errWait := c.Wait(call)
+		if errWait != nil {
+			return errWait
+		}
+		onResponse(call.Request, res)
+	}
+	return nil
+}
+
+func (c *Client) Close() error {
+	errClose := c.conn.Close()
+	<-c.done
+	return errClose
+}
+
+func (c *Client) readLoop() {
+	defer close(c.done)
+
+	for {
+		res := types.SocketServerResponse{}
+		errRead := wire.ReadFrame(c.conn, &res)
+		if errRead == nil && res.XRequestId == "" && res.Status == types.SocketCallError {
+			// server reject the connection before it could read the request id
+			errRead = errors.New(res.Message)
+		}
+
+		if errRead != nil {
+			c.mu.Lock()
+			c.readErr = errRead
+			c.mu.Unlock()
+			return
+		}
+
+		c.mu.Lock()
+		response, ok := c.pending[res.XRequestId]
+		delete(c.pending, res.XRequestId)
+		c.mu.Unlock()
+
+		if ok {
+			response <- res
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+// field is single exported value, time and money are already formatted so every format
+// write the same text
+type field struct {
+	name  string
+	value any
+}
+
+// record is exported row keeping the column order
+type record []field
+
+// MarshalJSON write the record as object in column order
+func (r record) MarshalJSON() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		name, _ := json.Marshal(f.name)
+		value, errMarshal := json.Marshal(f.value)
+		if errMarshal != nil {
+			return nil, errMarshal
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (r record) strings() []string {
+	values := make([]string, 0, len(r))
+	for _, f := range r {
+		values = append(values, fmt.Sprint(f.value))
+	}
+	return values
+}
+
+type stateColumn struct {
+	name  string
+	value func(slot types.Slot, car *types.Car) any
+}
+
+type sessionColumn struct {
+	name  string
+	value func(s types.Session) any
+}
+
+// stateColumns is every column of the state dataset, empty for free slot
+var stateColumns = []stateColumn{
+	{"slot", func(slot types.Slot, _ *types.Car) any { return slot.Number }},
+	{"label", func(slot types.Slot, _ *types.Car) any { return slot.Label }},
+	{"level", func(slot types.Slot, _ *types.Car) any { return slot.Level }},
+	{"zone", func(slot types.Slot, _ *types.Car) any { return slot.Zone }},
+	{"slot_class", func(slot types.Slot, _ *types.Car) any { return slot.Class }},
+	{"status", func(slot types.Slot, car *types.Car) any { return slotStatus(slot, car) }},
+	{"plate", carValue(func(car *types.Car) any { return car.PoliceNumber })},
+	{"class", carValue(func(car *types.Car) any { return car.GetClass() })},
+	{"color", carValue(func(car *types.Car) any { return car.Color })},
+	{"make", carValue(func(car *types.Car) any { return car.Make })},
+	{"model", carValue(func(car *types.Car) any { return car.Model })},
+	{"parked_at", carValue(func(car *types.Car) any { return timeOf(car.ParkingAt) })},
+	{"request_id", carValue(func(car *types.Car) any { return car.Id })},
+}
+
+// sessionColumns is every column of the sessions dataset
+var sessionColumns = []sessionColumn{
+	{"request_id", func(s types.Session) any { return s.RequestId }},
+	{"plate", func(s types.Session) any { return s.PoliceNumber }},
+	{"class", func(s types.Session) any { return s.Class }},
+	{"slot", func(s types.Session) any { return s.Slot }},
+	{"label", func(s types.Session) any { return s.SlotLabel }},
+	{"entry_at", func(s types.Session) any { return timeOf(s.EntryAt) }},
+	{"exit_at", func(s types.Session) any { return timeOf(s.ExitAt) }},
+	{"duration_minutes", func(s types.Session) any { return math.Round(s.Duration().Minutes()*10) / 10 }},
+	{"cost", func(s types.Session) any { return s.Cost.Amount() }},
+	{"discount", func(s types.Session) any { return s.Discount.Amount() }},
+	{"discount_reason", func(s types.Session) any { return s.DiscountReason }},
+	{"currency", func(s types.Session) any { return s.Cost.Currency }},
+	{"payment_id", func(s types.Session) any { return s.PaymentId }},
+	{"method", func(s types.Session) any { return s.Method }},
+}
+
+// ColumnsOf return every column name of the dataset in export order
+func ColumnsOf(dataset string) (names []string) {
+	switch dataset {
+	case DatasetState:
+		for _, column := range stateColumns {
+			names = append(names, column.name)
+		}
+	case DatasetSessions:
+		for _, column := range sessionColumns {
+			names = append(names, column.name)
+		}
+	}
+	return names
+}
+
+// headerOf return the chosen column, every column of the dataset when none is chosen
+func headerOf(dataset string, columns []string) []string {
+	if len(columns) == 0 {
+		return ColumnsOf(dataset)
+	}
+	return columns
+}
+
+func stateRecord(header []string, slot types.Slot, car *types.Car) record {
+	r := make(record, 0, len(header))
+	for _, name := range header {
+		for _, column := range stateColumns {
+			if column.name == name {
+				r = append(r, field{name: name, value: column.value(slot, car)})
+			}
+		}
+	}
+	return r
+}
+
+func sessionRecord(header []string, session types.Session) record {
+	r := make(record, 0, len(header))
+	for _, name := range header {
+		for _, column := range sessionColumns {
+			if column.name == name {
+				r = append(r, field{name: name, value: column.value(session)})
+			}
+		}
+	}
+	return r
+}
+
+// carValue read the value of the parked car, free slot has empty value
+func carValue(value func(car *types.Car) any) func(types.Slot, *types.Car) any {
+	return func(_ types.Slot, car *types.Car) any {
+		if car == nil {
+			return ""
+		}
+		return value(car)
+	}
+}
+
+// slotStatus is what the slot is doing, the parked car win over maintenance and reservation
+func slotStatus(slot types.Slot, car *types.Car) string {
+	switch {
+	case car != nil:
+		return "occupied"
+	case slot.Maintenance != nil:
+		return "disabled"
+	case slot.Reserved != nil:
+		return "reserved"
+	}
+	return "free"
+}
+
+// timeOf format the time in server local time, zero time is empty
+func timeOf(at time.Time) string {
+	if at.IsZero() {
+		return ""
+	}
+	return at.Local().Format(time.RFC3339)
+}
@@ -0,0 +1,225 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+// export format
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatJSON  = "json"
+)
+
+// exported dataset, state is every slot with the car parked on it
+const (
+	DatasetState    = "state"
+	DatasetSessions = "sessions"
+	DatasetAll      = "all"
+)
+
+var (
+	// Formats is every accepted export format
+	Formats = []string{FormatCSV, FormatJSONL, FormatJSON}
+
+	// Datasets is every accepted dataset
+	Datasets = []string{DatasetState, DatasetSessions, DatasetAll}
+)
+
+// Options select what is exported and how, zero value export every session column as csv
+type Options struct {
+	Dataset string
+	Format  string
+
+	// Columns keep only these column in this order, empty is every column of the dataset
+	Columns []string
+
+	// From and To keep the car parked and the session overlapping the range, zero is unbounded
+	From time.Time
+	To   time.Time
+
+	// Output is the file written, empty is stdout
+	Output string
+}
+
+// GetDataset return the exported dataset, sessions when not given
+func (o Options) GetDataset() string {
+	if o.Dataset == "" {
+		return DatasetSessions
+	}
+	return o.Dataset
+}
+
+// GetFormat return the export format, csv when not given
+func (o Options) GetFormat() string {
+	if o.Format == "" {
+		return FormatCSV
+	}
+	return o.Format
+}
+
+// Validate check the dataset, format and column, csv and column selection need single dataset
+func (o Options) Validate() error {
+	dataset, format := o.GetDataset(), o.GetFormat()
+	if !oneOf(dataset, Datasets) {
+		return fmt.Errorf("unknown export dataset: `%s`, expected one of %s", dataset, strings.Join(Datasets, ", "))
+	}
+	if !oneOf(format, Formats) {
+		return fmt.Errorf("unknown export format: `%s`, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+
+	if !o.To.IsZero() && o.To.Before(o.From) {
+		return fmt.Errorf("export range must end after it start")
+	}
+
+	if dataset == DatasetAll {
+		if format == FormatCSV {
+			return fmt.Errorf("csv export single dataset, choose %s or %s", DatasetState, DatasetSessions)
+		}
+		if len(o.Columns) > 0 {
+			return fmt.Errorf("column selection need single dataset, choose %s or %s", DatasetState, DatasetSessions)
+		}
+		return nil
+	}
+
+	known := ColumnsOf(dataset)
+	for _, column := range o.Columns {
+		if !oneOf(column, known) {
+			return fmt.Errorf("unknown %s column: `%s`, expected one of %s", dataset, column, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// Lot is the exported lot, its current state and completed session
+type Lot struct {
+	Name       string
+	Status     types.AppStatus
+	Sessions   []types.Session
+	ExportedAt time.Time
+}
+
+// Write export the lot into w, the session and car outside the range are left out
+func Write(w io.Writer, lot Lot, opt Options) (rows int, err error) {
+	if err = opt.Validate(); err != nil {
+		return
+	}
+
+	datasets := []string{opt.GetDataset()}
+	if datasets[0] == DatasetAll {
+		datasets = []string{DatasetState, DatasetSessions}
+	}
+
+	records := make(map[string][]record)
+	for _, dataset := range datasets {
+		records[dataset] = recordsOf(dataset, lot, opt)
+		rows += len(records[dataset])
+	}
+
+	switch opt.GetFormat() {
+	case FormatCSV:
+		err = writeCSV(w, headerOf(datasets[0], opt.Columns), records[datasets[0]])
+	case FormatJSONL:
+		err = writeJSONL(w, datasets, records)
+	case FormatJSON:
+		err = writeJSON(w, lot, datasets, records)
+	}
+	return rows, err
+}
+
+// recordsOf select the row of the dataset in the range and keep the chosen column
+func recordsOf(dataset string, lot Lot, opt Options) []record {
+	header := headerOf(dataset, opt.Columns)
+	records := []record{}
+
+	switch dataset {
+	case DatasetState:
+		for i, slot := range lot.Status.Slots {
+			var car *types.Car
+			if i < len(lot.Status.CarList) {
+				car = lot.Status.CarList[i]
+			}
+
+			// ranged export only keep the car parked inside the range
+			if !opt.From.IsZero() || !opt.To.IsZero() {
+				if car == nil || car.ParkingAt.Before(opt.From) || (!opt.To.IsZero() && !car.ParkingAt.Before(opt.To)) {
+					continue
+				}
+			}
+			records = append(records, stateRecord(header, slot, car))
+		}
+	case DatasetSessions:
+		query := types.HistoryQuery{From: opt.From, To: opt.To}
+		for _, session := range lot.Sessions {
+			if query.Match(session) {
+				records = append(records, sessionRecord(header, session))
+			}
+		}
+	}
+	return records
+}
+
+func writeCSV(w io.Writer, header []string, records []record) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := writer.Write(r.strings()); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeJSONL write one json object per row, exporting every dataset tag the row with its dataset
+func writeJSONL(w io.Writer, datasets []string, records map[string][]record) error {
+	encoder := json.NewEncoder(w)
+	for _, dataset := range datasets {
+		for _, r := range records[dataset] {
+			if len(datasets) > 1 {
+				r = append(record{{name: "dataset", value: dataset}}, r...)
+			}
+			if err := encoder.Encode(r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeJSON write single document with the lot summary and the row of every dataset
+func writeJSON(w io.Writer, lot Lot, datasets []string, records map[string][]record) error {
+	document := record{
+		{name: "lot", value: lot.Name},
+		{name: "exported_at", value: lot.ExportedAt.Local().Format(time.RFC3339)},
+		{name: "capacity", value: lot.Status.LotParkingCapacity},
+		{name: "revenue", value: lot.Status.Revenue.Amount()},
+		{name: "currency", value: lot.Status.Revenue.Currency},
+		{name: "tx_count", value: lot.Status.TxCount},
+	}
+	for _, dataset := range datasets {
+		document = append(document, field{name: dataset, value: records[dataset]})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+func oneOf(value string, list []string) bool {
+	for _, known := range list {
+		if value == known {
+			return true
+		}
+	}
+	return false
+}
@@ -4,10 +4,14 @@ import (
 	"bufio"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/khafidprayoga/parking-app/internal/export"
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/plate"
 	"github.com/khafidprayoga/parking-app/internal/types"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func ParseImportCmd(filePath string) (cmdList []types.Socket, err error) {
@@ -36,13 +40,32 @@ func ParseImportCmd(filePath string) (cmdList []types.Socket, err error) {
 		// parse instruction set
 		strCmd := strings.Split(strings.TrimSpace(line), " ")
 		cmd := strCmd[0]
-		args := strCmd[1:]
+		lot, args := ExtractLot(strCmd[1:])
 
 		allowedCommands := map[string]struct{}{
-			types.CmdCreateStore: {},
-			types.CmdPark:        {},
-			types.CmdLeave:       {},
-			types.CmdStatus:      {},
+			types.CmdCreateStore:       {},
+			types.CmdResize:            {},
+			types.CmdDisableSlot:       {},
+			types.CmdEnableSlot:        {},
+			types.CmdReserve:           {},
+			types.CmdCancelReservation: {},
+			types.CmdAddMember:         {},
+			types.CmdRemoveMember:      {},
+			types.CmdMembers:           {},
+			types.CmdPark:              {},
+			types.CmdLeave:             {},
+			types.CmdRefund:            {},
+			types.CmdVoid:              {},
+			types.CmdPayments:          {},
+			types.CmdStatus:            {},
+			types.CmdHistory:           {},
+			types.CmdReport:            {},
+			types.CmdFind:              {},
+			types.CmdSearch:            {},
+			types.CmdSlot:              {},
+			types.CmdPlatesByColor:     {},
+			types.CmdSlotsByColor:      {},
+			types.CmdSlotByPlate:       {},
 		}
 
 		if _, ok := allowedCommands[cmd]; !ok {
@@ -57,55 +80,270 @@ func ParseImportCmd(filePath string) (cmdList []types.Socket, err error) {
 				return
 			}
 
-			parkingLotCap := args[0]
+			// plain capacity or typed slot group, e.g. `car=20 moto=10`
+			parkingLotCap := strings.Join(args, " ")
 
 			socketCommand = append(socketCommand, types.Socket{
 				Command:    cmd,
+				Lot:        lot,
 				Data:       parkingLotCap,
 				XRequestId: uuid.NewString(),
 			})
 
+		case types.CmdResize:
+			resize := ParseResizeArgs(args)
+			if resize.Layout == "" {
+				err = fmt.Errorf("lot capacity not specified at this instruction `%s`", line)
+				return
+			}
+
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       resize,
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdDisableSlot, types.CmdEnableSlot:
+			slot, errParse := ParseSlotArgs(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
+
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       slot,
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdReserve:
+			reservation, errParse := ParseReserveArgs(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
+
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       reservation,
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdCancelReservation:
+			policeNumber, errParse := ParsePlate(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
+
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       types.ReservationDTO{PoliceNumber: policeNumber},
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdAddMember:
+			member, errParse := ParseMemberArgs(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
+
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       member,
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdRemoveMember:
+			policeNumber, errParse := ParsePlate(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
+
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       types.MemberDTO{PoliceNumber: policeNumber},
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdMembers:
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				XRequestId: uuid.NewString(),
+			})
 		case types.CmdPark:
-			policeNumber := strings.Join(args, "")
-			if len(policeNumber) == 0 {
+			car, errParse := ParseParkArgs(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
+
+			if len(car.PoliceNumber) == 0 {
 				//skipping invalid or malformed string
 				continue
 			}
 
 			req := types.Socket{
-				Command: cmd,
-				Data: types.CarDTO{
-					PoliceNumber: policeNumber,
-				},
+				Command:    cmd,
+				Lot:        lot,
+				Data:       car,
 				XRequestId: uuid.NewString(),
 			}
 			socketCommand = append(socketCommand, req)
 		case types.CmdLeave:
-			// join string -1 before the hours parameter
-			policeNumber := strings.Join(args[0:len(args)-1], "")
+			car, errParse := ParseLeaveArgs(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
 
-			// last is the hours count
-			hours := args[len(args)-1]
+			req := types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       car,
+				XRequestId: uuid.NewString(),
+			}
 
-			durationInHours, errParseDur := strconv.Atoi(hours)
-			if errParseDur != nil {
-				err = fmt.Errorf("error on parsing hours: %s at this instruction `%s` ", errParseDur.Error(), line)
+			socketCommand = append(socketCommand, req)
+		case types.CmdRefund, types.CmdVoid:
+			refund, errParse := ParseRefundArgs(args, cmd == types.CmdRefund)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
 				return
 			}
 
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       refund,
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdPayments:
+			query, errParse := ParsePaymentArgs(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
+
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       query,
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdStatus:
 			req := types.Socket{
-				Command: cmd,
-				Data: types.CarDTO{
-					PoliceNumber: policeNumber,
-					Hours:        durationInHours,
-				},
+				Command:    cmd,
+				Lot:        lot,
 				XRequestId: uuid.NewString(),
 			}
 
+			if len(args) > 0 {
+				filter, errParse := ParseStatusArgs(args)
+				if errParse != nil {
+					err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+					return
+				}
+				req.Data = filter
+			}
+
 			socketCommand = append(socketCommand, req)
-		case types.CmdStatus:
+		case types.CmdFind:
+			policeNumber, errParse := ParsePlate(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
+
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       types.CarDTO{PoliceNumber: policeNumber},
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdSearch:
+			filter, errParse := ParseSearchArgs(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
+
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       filter,
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdPlatesByColor, types.CmdSlotsByColor:
+			if len(args) == 0 {
+				err = fmt.Errorf("color not specified at this instruction `%s`", line)
+				return
+			}
+
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       strings.Join(args, " "),
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdSlotByPlate:
+			policeNumber, errParse := ParsePlate(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
+
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       types.CarDTO{PoliceNumber: policeNumber},
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdSlot:
+			slot, errParse := ParseSlotArgs(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
+
 			socketCommand = append(socketCommand, types.Socket{
 				Command:    cmd,
+				Lot:        lot,
+				Data:       types.SlotDTO{Number: slot.Number},
+				XRequestId: uuid.NewString(),
+			})
+		case types.CmdHistory:
+			req := types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				XRequestId: uuid.NewString(),
+			}
+
+			if len(args) > 0 {
+				query, errParse := ParseHistoryArgs(args)
+				if errParse != nil {
+					err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+					return
+				}
+				req.Data = query
+			}
+
+			socketCommand = append(socketCommand, req)
+		case types.CmdReport:
+			// the output format only matter to the cli
+			query, _, errParse := ParseReportArgs(args)
+			if errParse != nil {
+				err = fmt.Errorf("%s at this instruction `%s`", errParse.Error(), line)
+				return
+			}
+
+			socketCommand = append(socketCommand, types.Socket{
+				Command:    cmd,
+				Lot:        lot,
+				Data:       query,
 				XRequestId: uuid.NewString(),
 			})
 		}
@@ -114,3 +352,445 @@ func ParseImportCmd(filePath string) (cmdList []types.Socket, err error) {
 	_ = file.Close()
 	return socketCommand, nil
 }
+
+// ParseParkArgs read `park` arguments, key=value token is vehicle attribute
+// (class, color, make, model and photo) and the rest is joined into the police number,
+// e.g. `B 1234 ABC color=white make=Toyota model=Avanza`
+func ParseParkArgs(args []string) (car types.CarDTO, err error) {
+	tokens := []string{}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			tokens = append(tokens, arg)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "class":
+			car.Class = value
+		case "color", "colour":
+			car.Color = value
+		case "make":
+			car.Make = value
+		case "model":
+			car.Model = value
+		case "photo":
+			car.Photo = value
+		default:
+			err = fmt.Errorf("unknown park attribute: `%s`", key)
+			return
+		}
+	}
+
+	// no plate is skipped by the import
+	if len(tokens) > 0 {
+		car.PoliceNumber, err = ParsePlate(tokens)
+	}
+	return
+}
+
+// ParseMemberArgs read `add_member` arguments, the police number then optional
+// `months=` and `name=`, e.g. `B 1234 ABC months=3 name=Budi`
+func ParseMemberArgs(args []string) (member types.MemberDTO, err error) {
+	tokens := []string{}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			tokens = append(tokens, arg)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "months":
+			months, errCv := strconv.Atoi(value)
+			if errCv != nil {
+				err = fmt.Errorf("error on parsing months: %s", errCv.Error())
+				return
+			}
+			member.Months = months
+		case "name":
+			member.Name = value
+		default:
+			err = fmt.Errorf("unknown member attribute: `%s`", key)
+			return
+		}
+	}
+
+	member.PoliceNumber, err = ParsePlate(tokens)
+	return
+}
+
+// ParseLeaveArgs read `leave` arguments, the police number then optional `hours=` and
+// `method=` payment method, the legacy `KA-01-HH-1234 4` form is kept for single word plate
+func ParseLeaveArgs(args []string) (car types.CarDTO, err error) {
+	tokens := []string{}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			tokens = append(tokens, arg)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "hours":
+			hours, errCv := strconv.Atoi(value)
+			if errCv != nil {
+				err = fmt.Errorf("error on parsing hours: %s", errCv.Error())
+				return
+			}
+			car.Hours = hours
+		case "method":
+			car.Method = value
+		default:
+			err = fmt.Errorf("unknown leave attribute: `%s`", key)
+			return
+		}
+	}
+
+	if hours, ok := legacyLeaveHours(args); ok {
+		tokens = tokens[:1]
+		car.Hours = hours
+	}
+
+	car.PoliceNumber, err = ParsePlate(tokens)
+	return
+}
+
+// legacyLeaveHours read the hours of exactly `<plate> <hours>`, the plate must be a whole one
+// word plate with letter and digit so spaced plate such as `B 1234` is never taken as hours
+func legacyLeaveHours(args []string) (hours int, ok bool) {
+	if len(args) != 2 || strings.Contains(args[0], "=") || !strings.ContainsAny(args[0], "0123456789") {
+		return
+	}
+	if _, errPlate := plate.Normalize(args[0]); errPlate != nil || strings.Trim(plate.Key(args[0]), "0123456789") == "" {
+		return
+	}
+
+	hours, errCv := strconv.Atoi(args[1])
+	return hours, errCv == nil
+}
+
+// ParseRefundArgs read `refund` and `void` arguments, the payment id then optional amount
+// (refund only) and the reason, e.g. `3f2a... 5 wrong slot`
+func ParseRefundArgs(args []string, withAmount bool) (refund types.RefundDTO, err error) {
+	if len(args) == 0 {
+		err = fmt.Errorf("payment id not specified")
+		return
+	}
+
+	refund.PaymentId, args = args[0], args[1:]
+	if withAmount && len(args) > 0 {
+		if amount, errCv := money.Parse(args[0], ""); errCv == nil {
+			refund.Amount, args = amount, args[1:]
+		}
+	}
+
+	refund.Reason = strings.Join(args, " ")
+	return
+}
+
+// ParsePaymentArgs read `payments` arguments, optional police number then `method=`
+func ParsePaymentArgs(args []string) (query types.PaymentQuery, err error) {
+	tokens := []string{}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			tokens = append(tokens, arg)
+			continue
+		}
+
+		if !strings.EqualFold(key, "method") {
+			err = fmt.Errorf("unknown payment filter: `%s`", key)
+			return
+		}
+		query.Method = value
+	}
+
+	if len(tokens) > 0 {
+		query.PoliceNumber, err = ParsePlate(tokens)
+	}
+	return
+}
+
+// ParsePlate join the plate token, e.g. `B 1234 ABC` typed as three argument,
+// and validate it into its display form
+func ParsePlate(args []string) (policeNumber string, err error) {
+	if len(args) == 0 {
+		err = fmt.Errorf("police number not specified")
+		return
+	}
+	return plate.Normalize(strings.Join(args, " "))
+}
+
+// ParseReserveArgs read `reserve` arguments, the police number then the from and to time
+// and optional `slot=n` and `class=x`, e.g. `B1234ABC 2024-05-01T09:00 2024-05-01T12:00 slot=3`
+func ParseReserveArgs(args []string) (reservation types.ReservationDTO, err error) {
+	tokens := []string{}
+	window := []time.Time{}
+	for _, arg := range args {
+		if at, errTime := types.ParseTime(arg); errTime == nil {
+			window = append(window, at)
+			continue
+		}
+
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			tokens = append(tokens, arg)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "class":
+			reservation.Class = value
+		case "slot":
+			number, errCv := strconv.Atoi(value)
+			if errCv != nil {
+				err = fmt.Errorf("error on parsing slot number: %s", errCv.Error())
+				return
+			}
+			reservation.Slot = number
+		default:
+			err = fmt.Errorf("unknown reserve attribute: `%s`", key)
+			return
+		}
+	}
+
+	if len(tokens) == 0 {
+		err = fmt.Errorf("police number not specified")
+		return
+	}
+
+	if len(window) != 2 {
+		err = fmt.Errorf("reservation must have from and to time, e.g. 2024-05-01T09:00")
+		return
+	}
+
+	if reservation.PoliceNumber, err = ParsePlate(tokens); err != nil {
+		return
+	}
+	reservation.From, reservation.To = window[0], window[1]
+	return
+}
+
+// ParseStatusArgs read `status` filter, e.g. `level=2 zone=B`
+func ParseStatusArgs(args []string) (filter types.StatusFilter, err error) {
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			err = fmt.Errorf("status filter `%s` must be key=value", arg)
+			return
+		}
+
+		switch strings.ToLower(key) {
+		case "level":
+			level, errCv := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(value), "L"))
+			if errCv != nil {
+				err = fmt.Errorf("error on parsing level: %s", errCv.Error())
+				return
+			}
+			filter.Level = level
+		case "zone":
+			filter.Zone = value
+		default:
+			err = fmt.Errorf("unknown status filter: `%s`", key)
+			return
+		}
+	}
+	return
+}
+
+// ParseSearchArgs read `search` arguments, bare token is the color and the rest is
+// key=value filter, e.g. `white make=Toyota class=car`
+func ParseSearchArgs(args []string) (filter types.CarFilter, err error) {
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			filter.Color = arg
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "color", "colour":
+			filter.Color = value
+		case "make":
+			filter.Make = value
+		case "class":
+			filter.Class = value
+		default:
+			err = fmt.Errorf("unknown search filter: `%s`", key)
+			return
+		}
+	}
+	return
+}
+
+// ParseHistoryArgs read `history` arguments, optional police number then `from=` and `to=` time,
+// e.g. `B1234ABC from=2024-05-01 to=2024-05-02`
+func ParseHistoryArgs(args []string) (query types.HistoryQuery, err error) {
+	tokens := []string{}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			tokens = append(tokens, arg)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "from":
+			query.From, err = types.ParseTime(value)
+		case "to":
+			query.To, err = types.ParseTime(value)
+		default:
+			err = fmt.Errorf("unknown history filter: `%s`", key)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	if len(tokens) > 0 {
+		query.PoliceNumber, err = ParsePlate(tokens)
+	}
+	return
+}
+
+// report output format of the cli
+const (
+	FormatTable = "table"
+	FormatCSV   = "csv"
+)
+
+// ParseReportArgs read `report` arguments, optional period then `from=`, `to=` time and
+// `format=table|csv`, e.g. `hourly from=2024-05-01 to=2024-05-02 format=csv`
+func ParseReportArgs(args []string) (query types.ReportQuery, format string, err error) {
+	format = FormatTable
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			key, value = "period", arg
+		}
+
+		switch strings.ToLower(key) {
+		case "period":
+			query.Period = strings.ToLower(value)
+		case "from":
+			query.From, err = types.ParseTime(value)
+		case "to":
+			query.To, err = types.ParseTime(value)
+		case "format":
+			format = strings.ToLower(value)
+			if format != FormatTable && format != FormatCSV {
+				err = fmt.Errorf("unknown report format: `%s`, expected table or csv", value)
+			}
+		default:
+			err = fmt.Errorf("unknown report filter: `%s`", key)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ParseExportArgs read `export` arguments, optional dataset then `format=`, comma separated
+// `columns=`, `from=`, `to=` time and `out=` file, e.g. `sessions format=csv columns=plate,cost out=june.csv`
+func ParseExportArgs(args []string) (opt export.Options, err error) {
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			key, value = "dataset", arg
+		}
+
+		switch strings.ToLower(key) {
+		case "dataset":
+			opt.Dataset = strings.ToLower(value)
+		case "format":
+			opt.Format = strings.ToLower(value)
+		case "columns":
+			for _, column := range strings.Split(value, ",") {
+				if column = strings.ToLower(strings.TrimSpace(column)); column != "" {
+					opt.Columns = append(opt.Columns, column)
+				}
+			}
+		case "from":
+			opt.From, err = types.ParseTime(value)
+		case "to":
+			opt.To, err = types.ParseTime(value)
+		case "out":
+			opt.Output = value
+		default:
+			err = fmt.Errorf("unknown export option: `%s`", key)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	err = opt.Validate()
+	return
+}
+
+// ExtractLot pull the `--lot name` (or `--lot=name`) target lot out of the command arguments
+func ExtractLot(args []string) (lot string, rest []string) {
+	rest = []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--lot" || arg == "-lot":
+			if i+1 < len(args) {
+				lot = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(arg, "--lot="):
+			lot = strings.TrimPrefix(arg, "--lot=")
+		case strings.HasPrefix(arg, "-lot="):
+			lot = strings.TrimPrefix(arg, "-lot=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return
+}
+
+// ParseResizeArgs read `resize_parking_lot` arguments, the new layout and optional --drain
+func ParseResizeArgs(args []string) (resize types.ResizeDTO) {
+	layout := []string{}
+	for _, arg := range args {
+		if arg == "--drain" || arg == "-drain" {
+			resize.Drain = true
+			continue
+		}
+		layout = append(layout, arg)
+	}
+
+	resize.Layout = strings.Join(layout, " ")
+	return
+}
+
+// ParseSlotArgs read `disable_slot` and `enable_slot` arguments, the slot number
+// then optional reason words and --force
+func ParseSlotArgs(args []string) (slot types.SlotDTO, err error) {
+	if len(args) == 0 {
+		err = fmt.Errorf("slot number not specified")
+		return
+	}
+
+	number, errCv := strconv.Atoi(args[0])
+	if errCv != nil {
+		err = fmt.Errorf("error on parsing slot number: %s", errCv.Error())
+		return
+	}
+	slot.Number = number
+
+	reason := []string{}
+	for _, arg := range args[1:] {
+		if arg == "--force" || arg == "-force" {
+			slot.Force = true
+			continue
+		}
+		reason = append(reason, arg)
+	}
+
+	slot.Reason = strings.Join(reason, " ")
+	return
+}
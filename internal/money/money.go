@@ -0,0 +1,281 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrMalformed is returned for amount that is not a plain decimal, e.g. `10`, `-2.50`
+var ErrMalformed = errors.New("malformed money amount")
+
+// plainExponent is the decimal place of amount not put in any currency yet
+const plainExponent = 2
+
+// exponents is the decimal place of the currency minor unit, currency not listed has two
+var exponents = map[string]int{
+	// no minor unit in use
+	"IDR": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"CLP": 0,
+	"ISK": 0,
+
+	// thousandth minor unit
+	"BHD": 3,
+	"JOD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+}
+
+// Money is exact amount counted in the minor unit of its currency, e.g. 1050 USD is 10.50
+// while 1050 IDR is 1050 rupiah as IDR has no minor unit. Amount without currency is plain
+// number of the tariff file, request or state written before Money existed, it is counted
+// in hundredth until put In a currency.
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// Exponent return the decimal place of the currency minor unit
+func Exponent(currency string) int {
+	if currency == "" {
+		return plainExponent
+	}
+	if exponent, ok := exponents[strings.ToUpper(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// FromMinor return the amount counted in minor unit, e.g. FromMinor(1050, "USD") is 10.50 USD
+func FromMinor(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// FromUnits return the whole amount, e.g. FromUnits(10, "USD") is 10.00 USD
+func FromUnits(units int64, currency string) Money {
+	return Money{Minor: units * pow10(Exponent(currency)), Currency: currency}
+}
+
+// Parse read plain decimal amount, digit beyond the currency minor unit is rounded half
+// away from zero, e.g. `10.005` USD is 10.01 USD and `10.5` IDR is 11 IDR
+func Parse(amount, currency string) (m Money, err error) {
+	s := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(s, "-")
+	if negative || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		err = fmt.Errorf("%w: %q", ErrMalformed, amount)
+		return
+	}
+
+	exponent := Exponent(currency)
+	fraction += strings.Repeat("0", exponent+1)
+	kept, dropped := fraction[:exponent], fraction[exponent]
+
+	minor, errParse := strconv.ParseInt("0"+whole+kept, 10, 64)
+	if errParse != nil {
+		err = fmt.Errorf("%w: %q is too large", ErrMalformed, amount)
+		return
+	}
+	if dropped >= '5' {
+		minor++
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// In put the amount into the currency rounding half away from zero, amount already in
+// another currency is returned as it is, there is no exchange rate
+func (m Money) In(currency string) Money {
+	if m.Currency != "" || currency == "" {
+		return m
+	}
+	return Money{Minor: rescale(m.Minor, plainExponent, Exponent(currency)), Currency: currency}
+}
+
+// Add return m + o, amount without currency take the currency of the other
+func (m Money) Add(o Money) Money {
+	m, o = align(m, o)
+	return Money{Minor: m.Minor + o.Minor, Currency: m.Currency}
+}
+
+// Sub return m - o, amount without currency take the currency of the other
+func (m Money) Sub(o Money) Money {
+	return m.Add(o.Neg())
+}
+
+// Neg return -m
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Currency}
+}
+
+// Cmp return -1, 0 or +1 as m is less than, equal to or greater than o
+func (m Money) Cmp(o Money) int {
+	m, o = align(m, o)
+	switch {
+	case m.Minor < o.Minor:
+		return -1
+	case m.Minor > o.Minor:
+		return 1
+	}
+	return 0
+}
+
+// Sign return -1, 0 or +1 as m is negative, zero or positive
+func (m Money) Sign() int {
+	switch {
+	case m.Minor < 0:
+		return -1
+	case m.Minor > 0:
+		return 1
+	}
+	return 0
+}
+
+// IsZero report whether the amount is zero, whatever its currency
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+// Percent return pct percent of m rounded half away from zero to the minor unit
+func (m Money) Percent(pct float64) Money {
+	return Money{Minor: int64(math.Round(float64(m.Minor) * pct / 100)), Currency: m.Currency}
+}
+
+// Amount format the amount as plain decimal with every place of the minor unit, e.g. `10.50`
+func (m Money) Amount() string {
+	exponent := Exponent(m.Currency)
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+
+	if exponent == 0 {
+		return sign + strconv.FormatInt(minor, 10)
+	}
+
+	unit := pow10(exponent)
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/unit, exponent, minor%unit)
+}
+
+// String format the amount with its currency, e.g. `10.50 USD`
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Amount()
+	}
+	return m.Amount() + " " + m.Currency
+}
+
+type jsonMoney struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON write {"amount": "10.50", "currency": "USD"}, the amount is string so no
+// reader lose a digit to float, amount without currency is written as plain number
+func (m Money) MarshalJSON() ([]byte, error) {
+	if m.Currency == "" {
+		return []byte(m.Amount()), nil
+	}
+
+	amount, _ := json.Marshal(m.Amount())
+	return json.Marshal(jsonMoney{Amount: amount, Currency: m.Currency})
+}
+
+// UnmarshalJSON read the object written by MarshalJSON, plain number like `10.5` and
+// string like `"10.50 USD"`
+func (m *Money) UnmarshalJSON(data []byte) (err error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case bytes.HasPrefix(data, []byte("{")):
+		object := jsonMoney{}
+		if err = json.Unmarshal(data, &object); err != nil {
+			return err
+		}
+		amount := string(object.Amount)
+		if bytes.HasPrefix(object.Amount, []byte(`"`)) {
+			if err = json.Unmarshal(object.Amount, &amount); err != nil {
+				return err
+			}
+		}
+		*m, err = Parse(amount, object.Currency)
+	case bytes.HasPrefix(data, []byte(`"`)):
+		text := ""
+		if err = json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		amount, currency, _ := strings.Cut(strings.TrimSpace(text), " ")
+		*m, err = Parse(amount, strings.TrimSpace(currency))
+	default:
+		*m, err = Parse(string(data), "")
+	}
+	return err
+}
+
+// align put amount without currency into the currency of the other
+func align(m, o Money) (Money, Money) {
+	switch {
+	case m.Currency == o.Currency:
+	case m.Currency == "":
+		m = m.In(o.Currency)
+	case o.Currency == "":
+		o = o.In(m.Currency)
+	default:
+		panic(fmt.Sprintf("money: %s and %s can not be mixed", m.Currency, o.Currency))
+	}
+	return m, o
+}
+
+// rescale move minor from one decimal place to another rounding half away from zero
+func rescale(minor int64, from, to int) int64 {
+	if to >= from {
+		return minor * pow10(to-from)
+	}
+
+	unit := pow10(from - to)
+	quotient, remainder := minor/unit, minor%unit
+	if remainder < 0 {
+		remainder = -remainder
+	}
+	if remainder*2 >= unit {
+		if minor < 0 {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+	return quotient
+}
+
+func pow10(n int) int64 {
+	result := int64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
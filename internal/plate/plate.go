@@ -0,0 +1,64 @@
+package plate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrMalformed is returned for plate that is empty, too short or too long,
+// or has character other than letter, digit, space, dash and dot
+var ErrMalformed = errors.New("malformed police number")
+
+const (
+	minKeyLength = 2
+	maxKeyLength = 12
+)
+
+// Normalize validate the plate and return its display form: upper cased, whitespace
+// collapsed into single space and no space around dash, e.g. ` b  1234 abc` → `B 1234 ABC`
+// and `ka - 01-hh-1234` → `KA-01-HH-1234`
+func Normalize(s string) (display string, err error) {
+	for _, r := range s {
+		if !isSeparator(r) && !isPlateRune(unicode.ToUpper(r)) {
+			err = fmt.Errorf("%w: %q has invalid character %q", ErrMalformed, s, r)
+			return
+		}
+	}
+
+	if key := Key(s); len(key) < minKeyLength || len(key) > maxKeyLength {
+		err = fmt.Errorf("%w: %q must have %d to %d letter or digit", ErrMalformed, s, minKeyLength, maxKeyLength)
+		return
+	}
+
+	display = strings.Join(strings.Fields(strings.ToUpper(s)), " ")
+	display = strings.ReplaceAll(display, " -", "-")
+	display = strings.ReplaceAll(display, "- ", "-")
+	return display, nil
+}
+
+// Key is the identity of the plate, letter and digit only and upper cased, so regional
+// format written differently is the same vehicle, e.g. `B 1234 ABC`, `b1234abc` and `B-1234-ABC`
+func Key(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if isPlateRune(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Equal report whether both plate is the same vehicle
+func Equal(a, b string) bool {
+	return Key(a) == Key(b)
+}
+
+func isPlateRune(r rune) bool {
+	return (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func isSeparator(r rune) bool {
+	return unicode.IsSpace(r) || r == '-' || r == '.'
+}
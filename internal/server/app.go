@@ -1,13 +1,185 @@
 package server
 
-import "github.com/khafidprayoga/parking-app/contract"
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"unicode"
 
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+// LotFactory create the backend of new named lot, called once per lot on its create_parking_lot
+type LotFactory func(lot string) (contract.IParkingUseCase, error)
+
+// LotDiscard drop the backend and persisted data of the lot the factory just created, called
+// when its create_parking_lot is rejected
+type LotDiscard func(lot string)
+
+// ParkingAppServer host every named lot, each lot is independent backend with its own revenue
 type ParkingAppServer struct {
-	service contract.IParkingUseCase
+	mu         sync.RWMutex
+	lots       map[string]contract.IParkingUseCase
+	newLot     LotFactory
+	discardLot LotDiscard
+
+	// creating is closed once the in flight create of the lot is done, so the factory and
+	// the layout fsync run outside mu without two request creating the same lot
+	creating map[string]chan struct{}
 }
 
+// CreateAppServer host single service as the default lot
 func CreateAppServer(service contract.IParkingUseCase) *ParkingAppServer {
 	return &ParkingAppServer{
-		service: service,
+		lots: map[string]contract.IParkingUseCase{types.DefaultLot: service},
+	}
+}
+
+// CreateMultiLotServer host the already restored lots and create the new one with newLot,
+// discard is optional and drop the created lot whose layout is rejected
+func CreateMultiLotServer(lots map[string]contract.IParkingUseCase, newLot LotFactory, discard LotDiscard) *ParkingAppServer {
+	srv := &ParkingAppServer{
+		lots:       make(map[string]contract.IParkingUseCase),
+		newLot:     newLot,
+		discardLot: discard,
+		creating:   make(map[string]chan struct{}),
+	}
+	for name, service := range lots {
+		srv.lots[name] = service
+	}
+	return srv
+}
+
+// Lots return the hosted lot name in order
+func (srv *ParkingAppServer) Lots() []string {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+
+	names := make([]string, 0, len(srv.lots))
+	for name := range srv.lots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lot return the backend of the named lot, empty name is the default lot
+func (srv *ParkingAppServer) lot(name string) (service contract.IParkingUseCase, err error) {
+	name = lotName(name)
+
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+
+	service, ok := srv.lots[name]
+	if !ok {
+		err = fmt.Errorf("%w: %s", contract.ErrLotNotFound, name)
+	}
+	return
+}
+
+// openLot open the named lot, the lot is created when the server has factory. Created lot
+// only join the server once its layout is accepted, rejected one is discarded so later
+// request and next boot do not find it
+func (srv *ParkingAppServer) openLot(name string, layout types.LotLayout) (err error) {
+	name = lotName(name)
+	if err = validLotName(name); err != nil {
+		return
+	}
+
+	for {
+		srv.mu.Lock()
+		if service, ok := srv.lots[name]; ok {
+			srv.mu.Unlock()
+			return openArea(service, layout)
+		}
+
+		if srv.newLot == nil {
+			srv.mu.Unlock()
+			err = fmt.Errorf("%w: %s, this server host single lot only", contract.ErrLotNotFound, name)
+			return
+		}
+
+		// wait the other create of the same lot then look again, it may have been rejected
+		inFlight, busy := srv.creating[name]
+		if !busy {
+			break
+		}
+		srv.mu.Unlock()
+		<-inFlight
+	}
+
+	done := make(chan struct{})
+	srv.creating[name] = done
+	srv.mu.Unlock()
+
+	defer func() {
+		srv.mu.Lock()
+		delete(srv.creating, name)
+		srv.mu.Unlock()
+		close(done)
+	}()
+
+	return srv.createLot(name, layout)
+}
+
+// createLot create the lot outside the server lock and add it once its layout is accepted,
+// the caller hold the in flight create of the lot
+func (srv *ParkingAppServer) createLot(name string, layout types.LotLayout) (err error) {
+	service, errCreate := srv.newLot(name)
+	if errCreate != nil {
+		err = fmt.Errorf("failed to create lot %s: %w", name, errCreate)
+		return
+	}
+
+	if err = openArea(service, layout); err != nil {
+		srv.discard(name)
+		return
+	}
+
+	// the in flight guard keep it from happening, still never replace the hosted lot
+	srv.mu.Lock()
+	current, exists := srv.lots[name]
+	if !exists {
+		srv.lots[name] = service
+	}
+	srv.mu.Unlock()
+
+	if exists {
+		if current != service {
+			srv.discard(name)
+		}
+		err = fmt.Errorf("failed to open parking area: %w", contract.ErrAlreadyInitialized)
+	}
+	return
+}
+
+func (srv *ParkingAppServer) discard(name string) {
+	if srv.discardLot != nil {
+		srv.discardLot(name)
+	}
+}
+
+func openArea(service contract.IParkingUseCase, layout types.LotLayout) error {
+	if errOpen := service.OpenParkingArea(layout); errOpen != nil {
+		return fmt.Errorf("failed to open parking area: %w", errOpen)
+	}
+	return nil
+}
+
+func lotName(name string) string {
+	if name == "" {
+		return types.DefaultLot
+	}
+	return name
+}
+
+// validLotName keep the name safe to be used as directory name
+func validLotName(name string) error {
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return fmt.Errorf("%w: lot name %s must be alphanumeric, - or _", contract.ErrInvalidRequest, name)
+		}
 	}
+	return nil
 }
@@ -1,76 +1,568 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/khafidprayoga/parking-app/contract"
 	"github.com/khafidprayoga/parking-app/internal/types"
 )
 
-func (srv *ParkingAppServer) HandleIncomingMsg(msg types.Socket) (response string, err error) {
+// HandleIncomingMsg run the socket command, response is human readable message
+// and data is the structured result for command that has one
+func (srv *ParkingAppServer) HandleIncomingMsg(msg types.Socket) (response string, data any, err error) {
 	switch msg.Command {
 	case types.CmdCreateStore:
-		parkingCap, errCv := strconv.Atoi(msg.Data.(string))
-		if errCv != nil {
-			err = fmt.Errorf("failed to convert string to int at %s actions", msg.Command)
+		strLayout, ok := msg.Data.(string)
+		if !ok {
+			err = fmt.Errorf("%w: lot capacity must be string at %s actions", contract.ErrInvalidRequest, msg.Command)
 			return
 		}
 
-		errOpen := srv.service.OpenParkingArea(parkingCap)
-		if err != nil {
-			err = fmt.Errorf("failed to open parking area: %s", errOpen.Error())
+		// either plain capacity `6` or typed slot `car=20 moto=10 ev=4`
+		layout, errParse := types.ParseLotLayout(strLayout)
+		if errParse != nil {
+			err = fmt.Errorf("%w: %v at %s actions", contract.ErrInvalidRequest, errParse, msg.Command)
 			return
 		}
 
-		response = fmt.Sprintf("success initalize parking lot with %v capacity", parkingCap)
+		// first create of the lot name bring up its backend
+		if err = srv.openLot(msg.Lot, layout); err != nil {
+			return
+		}
+
+		response = fmt.Sprintf("success initalize parking lot %s with %v capacity (%s)", lotName(msg.Lot), layout.Capacity(), layout)
+		return
+	case types.CmdResize:
+		resizeData := types.ResizeDTO{}
+		if err = decodeData(msg, &resizeData); err != nil {
+			return
+		}
+
+		layout, errParse := types.ParseLotLayout(resizeData.Layout)
+		if errParse != nil {
+			err = fmt.Errorf("%w: %v at %s actions", contract.ErrInvalidRequest, errParse, msg.Command)
+			return
+		}
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		errResize := service.ResizeParkingArea(layout, resizeData.Drain)
+		if errResize != nil {
+			err = fmt.Errorf("failed to resize parking area: %w", errResize)
+			return
+		}
+
+		response = fmt.Sprintf("success resize parking lot %s to %v capacity (%s)", lotName(msg.Lot), layout.Capacity(), layout)
+		return
+	case types.CmdDisableSlot, types.CmdEnableSlot:
+		slotData := types.SlotDTO{}
+		if err = decodeData(msg, &slotData); err != nil {
+			return
+		}
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		if msg.Command == types.CmdEnableSlot {
+			if errEnable := service.EnableSlot(slotData.Number); errEnable != nil {
+				err = fmt.Errorf("failed to enable slot %d: %w", slotData.Number, errEnable)
+				return
+			}
+
+			response = fmt.Sprintf("slot %d is enabled", slotData.Number)
+			return
+		}
+
+		if errDisable := service.DisableSlot(slotData.Number, slotData.Reason, slotData.Force); errDisable != nil {
+			err = fmt.Errorf("failed to disable slot %d: %w", slotData.Number, errDisable)
+			return
+		}
+
+		response = fmt.Sprintf("slot %d is disabled for maintenance", slotData.Number)
+		return
+	case types.CmdReserve:
+		reservationData := types.ReservationDTO{}
+		if err = decodeData(msg, &reservationData); err != nil {
+			return
+		}
+		reservationData.RequestId = msg.XRequestId
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		reservation, errReserve := service.Reserve(reservationData)
+		if errReserve != nil {
+			err = fmt.Errorf("failed to reserve slot, %w", errReserve)
+			return
+		}
+
+		response = fmt.Sprintf(
+			"slot %d is reserved for %s from %s to %s, released when not arrived until %s",
+			reservation.Slot,
+			reservation.PoliceNumber,
+			reservation.From.Format(time.RFC3339),
+			reservation.To.Format(time.RFC3339),
+			reservation.ExpireAt.Format(time.RFC3339),
+		)
+		data = reservation
+		return
+	case types.CmdCancelReservation:
+		reservationData := types.ReservationDTO{}
+		if err = decodeData(msg, &reservationData); err != nil {
+			return
+		}
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		if errCancel := service.CancelReservation(reservationData.PoliceNumber); errCancel != nil {
+			err = fmt.Errorf("failed to cancel reservation of %s, %w", reservationData.PoliceNumber, errCancel)
+			return
+		}
+
+		response = fmt.Sprintf("reservation of %s is cancelled", reservationData.GetPoliceNumber())
+		return
+	case types.CmdAddMember:
+		memberData := types.MemberDTO{}
+		if err = decodeData(msg, &memberData); err != nil {
+			return
+		}
+		memberData.RequestId = msg.XRequestId
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		member, errMember := service.AddMember(memberData)
+		if errMember != nil {
+			err = fmt.Errorf("failed to register member %s, %w", memberData.PoliceNumber, errMember)
+			return
+		}
+
+		response = fmt.Sprintf("%s monthly pass is valid until %s", member.PoliceNumber, member.ValidUntil.Format(time.RFC3339))
+		data = member
+		return
+	case types.CmdRemoveMember:
+		memberData := types.MemberDTO{}
+		if err = decodeData(msg, &memberData); err != nil {
+			return
+		}
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		if errRemove := service.RemoveMember(memberData.PoliceNumber); errRemove != nil {
+			err = fmt.Errorf("failed to remove member %s, %w", memberData.PoliceNumber, errRemove)
+			return
+		}
+
+		response = fmt.Sprintf("%s is no longer a member", memberData.GetPoliceNumber())
+		return
+	case types.CmdMembers:
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		members, errList := service.Members()
+		if errList != nil {
+			err = fmt.Errorf("failed to list member, %w", errList)
+			return
+		}
+
+		response = fmt.Sprintf("found %d member", len(members))
+		data = members
 		return
 	case types.CmdPark:
-		incomingCarData := types.CarDTO{
-			RequestId:    msg.XRequestId,
-			PoliceNumber: msg.Data.(map[string]any)["police_number"].(string),
+		incomingCarData := types.CarDTO{}
+		if err = decodeData(msg, &incomingCarData); err != nil {
+			return
 		}
+		incomingCarData.RequestId = msg.XRequestId
 
-		areaId, errParking := srv.service.EnterArea(incomingCarData)
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		areaId, errParking := service.EnterArea(incomingCarData)
 		if errParking != nil {
-			err = fmt.Errorf("failed to enter area, %s", errParking.Error())
+			err = fmt.Errorf("failed to enter area, %w", errParking)
 			return
 		}
 
 		response = fmt.Sprintf(
-			"successfully parked car. with police number %s and SLOT number id %v",
-			incomingCarData.PoliceNumber,
+			"successfully parked %s. with police number %s and SLOT number id %v",
+			incomingCarData.GetClass(),
+			incomingCarData.GetPoliceNumber(),
 			areaId,
 		)
 		return
 	case types.CmdLeave:
-		incomingCarData := types.CarDTO{
-			PoliceNumber: msg.Data.(map[string]any)["police_number"].(string),
-			Hours:        int(msg.Data.(map[string]any)["hours"].(float64)),
+		incomingCarData := types.CarDTO{}
+		if err = decodeData(msg, &incomingCarData); err != nil {
+			return
 		}
 
-		metadata, errLeave := srv.service.LeaveArea(incomingCarData)
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		metadata, errLeave := service.LeaveArea(incomingCarData)
 		if errLeave != nil {
-			err = fmt.Errorf("failed to exit area with police id %s, %s", incomingCarData.PoliceNumber, errLeave.Error())
+			err = fmt.Errorf("failed to exit area with police id %s, %w", incomingCarData.PoliceNumber, errLeave)
 			return
 		}
 
+		elapsed := time.Duration(0)
+		if metadata.ExitAt != nil {
+			elapsed = metadata.ExitAt.Sub(metadata.ParkingAt).Round(time.Minute)
+		}
+
 		response = fmt.Sprintf(
-			"successfully leave car. with police number %s and total hours elapsed  %v on area number %d",
+			"successfully leave car. with police number %s and total time elapsed %v on area number %d, cost %v",
 			metadata.PoliceNumber,
-			incomingCarData.Hours,
+			elapsed,
 			metadata.AreaNumber,
+			metadata.Cost,
 		)
+		if metadata.Discount.Sign() > 0 {
+			response += fmt.Sprintf(" after %v %s discount", metadata.Discount, metadata.DiscountReason)
+		}
+		response += fmt.Sprintf(", paid by %s with payment id %s", metadata.Method, metadata.PaymentId)
+		data = types.SessionOf(metadata)
+		return
+	case types.CmdRefund, types.CmdVoid:
+		refundData := types.RefundDTO{}
+		if err = decodeData(msg, &refundData); err != nil {
+			return
+		}
+		refundData.RequestId = msg.XRequestId
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		adjust := service.Refund
+		if msg.Command == types.CmdVoid {
+			adjust = service.Void
+		}
+
+		payment, errAdjust := adjust(refundData)
+		if errAdjust != nil {
+			err = fmt.Errorf("failed to %s payment %s, %w", msg.Command, refundData.PaymentId, errAdjust)
+			return
+		}
+
+		response = fmt.Sprintf(
+			"payment %s of %s is %s, refunded %v of %v",
+			payment.Id,
+			payment.PoliceNumber,
+			payment.Status,
+			payment.Amount.Sub(payment.Remaining()),
+			payment.Amount,
+		)
+		data = payment
+		return
+	case types.CmdPayments:
+		query := types.PaymentQuery{}
+		if msg.Data != nil {
+			if err = decodeData(msg, &query); err != nil {
+				return
+			}
+		}
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		report, errReport := service.Payments(query)
+		if errReport != nil {
+			err = fmt.Errorf("failed to list payment, %w", errReport)
+			return
+		}
+
+		response = fmt.Sprintf("found %d payment, revenue %v", len(report.Payments), report.Revenue)
+		data = report
 		return
 	case types.CmdStatus:
-		dataBytes, errGetStatus := srv.service.Status()
+		// optional level and zone filter
+		filter := types.StatusFilter{}
+		if msg.Data != nil {
+			if err = decodeData(msg, &filter); err != nil {
+				return
+			}
+		}
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		status, errGetStatus := service.Status()
 		if errGetStatus != nil {
-			err = fmt.Errorf("failed to parking app status %s", errGetStatus.Error())
+			err = fmt.Errorf("failed to parking app status %w", errGetStatus)
+			return
+		}
+
+		response = fmt.Sprintf("parking lot with %d capacity", status.LotParkingCapacity)
+		data = status.Filter(filter)
+		return
+	case types.CmdFind:
+		carData := types.CarDTO{}
+		if err = decodeData(msg, &carData); err != nil {
+			return
+		}
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		car, errFind := service.FindCar(carData.PoliceNumber)
+		if errFind != nil {
+			err = fmt.Errorf("failed to find car, %w", errFind)
+			return
+		}
+
+		response = fmt.Sprintf(
+			"%s is parked at slot %d (%s) since %s",
+			car.PoliceNumber,
+			car.AreaNumber,
+			car.SlotLabel,
+			car.ParkingAt.Format(time.RFC3339),
+		)
+		if vehicle := vehicleOf(car); vehicle != "" {
+			response += ", " + vehicle
+		}
+		data = car
+		return
+	case types.CmdSearch:
+		filter := types.CarFilter{}
+		if msg.Data != nil {
+			if err = decodeData(msg, &filter); err != nil {
+				return
+			}
+		}
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		cars, errSearch := service.SearchCars(filter)
+		if errSearch != nil {
+			err = fmt.Errorf("failed to search car, %w", errSearch)
+			return
+		}
+
+		response = fmt.Sprintf("found %d parked car", len(cars))
+		data = cars
+		return
+	case types.CmdPlatesByColor, types.CmdSlotsByColor:
+		color, ok := msg.Data.(string)
+		if !ok {
+			err = fmt.Errorf("%w: color must be string at %s actions", contract.ErrInvalidRequest, msg.Command)
+			return
+		}
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		cars, errQuery := service.CarsByColor(color)
+		if errQuery != nil {
+			err = fmt.Errorf("failed to query car by color, %w", errQuery)
+			return
+		}
+
+		// classic answer is the comma separated list, e.g. `KA-01-HH-1234, KA-01-HH-9999`
+		plates, slots, answers := make([]string, len(cars)), make([]int, len(cars)), make([]string, len(cars))
+		for i, car := range cars {
+			plates[i], slots[i] = car.PoliceNumber, car.AreaNumber
+			answers[i] = car.PoliceNumber
+			if msg.Command == types.CmdSlotsByColor {
+				answers[i] = strconv.Itoa(car.AreaNumber)
+			}
+		}
+
+		response = "Not found"
+		if len(answers) > 0 {
+			response = strings.Join(answers, ", ")
+		}
+
+		data = plates
+		if msg.Command == types.CmdSlotsByColor {
+			data = slots
+		}
+		return
+	case types.CmdSlotByPlate:
+		carData := types.CarDTO{}
+		if err = decodeData(msg, &carData); err != nil {
+			return
+		}
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		car, errFind := service.FindCar(carData.PoliceNumber)
+		if errFind != nil {
+			err = fmt.Errorf("failed to find car, %w", errFind)
+			return
+		}
+
+		response = strconv.Itoa(car.AreaNumber)
+		data = car.AreaNumber
+		return
+	case types.CmdSlot:
+		slotData := types.SlotDTO{}
+		if err = decodeData(msg, &slotData); err != nil {
 			return
 		}
 
-		response = string(dataBytes)
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		detail, errSlot := service.SlotDetail(slotData.Number)
+		if errSlot != nil {
+			err = fmt.Errorf("failed to get slot %d, %w", slotData.Number, errSlot)
+			return
+		}
+
+		response = fmt.Sprintf("slot %d (%s, %s) is free", detail.Number, detail.Label, detail.Class)
+		if detail.Car != nil {
+			response = fmt.Sprintf(
+				"slot %d (%s, %s) is taken by %s since %s",
+				detail.Number,
+				detail.Label,
+				detail.Class,
+				detail.Car.PoliceNumber,
+				detail.Car.ParkingAt.Format(time.RFC3339),
+			)
+		}
+		data = detail
 		return
+	case types.CmdHistory:
+		// optional plate and time range
+		query := types.HistoryQuery{}
+		if msg.Data != nil {
+			if err = decodeData(msg, &query); err != nil {
+				return
+			}
+		}
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		sessions, errHistory := service.History(query)
+		if errHistory != nil {
+			err = fmt.Errorf("failed to get parking history %w", errHistory)
+			return
+		}
+
+		response = fmt.Sprintf("found %d parking session", len(sessions))
+		data = sessions
+		return
+	case types.CmdReport:
+		// optional period and time range
+		query := types.ReportQuery{}
+		if msg.Data != nil {
+			if err = decodeData(msg, &query); err != nil {
+				return
+			}
+		}
+
+		service, errLot := srv.lot(msg.Lot)
+		if errLot != nil {
+			err = errLot
+			return
+		}
+
+		report, errReport := service.Report(query)
+		if errReport != nil {
+			err = fmt.Errorf("failed to build report, %w", errReport)
+			return
+		}
+
+		response = fmt.Sprintf(
+			"%s report of %d period, revenue %v over %d session",
+			report.Period,
+			len(report.Rows),
+			report.Total.Revenue,
+			report.Total.Sessions,
+		)
+		data = report
+		return
+	}
+
+	err = fmt.Errorf("%w: unknown command `%s`", contract.ErrInvalidRequest, msg.Command)
+	return
+}
+
+// decodeData convert the generic socket payload into the command dto
+func decodeData(msg types.Socket, dst any) error {
+	dataBytes, errMarshal := json.Marshal(msg.Data)
+	if errMarshal != nil {
+		return fmt.Errorf("%w: malformed data at %s actions", contract.ErrInvalidRequest, msg.Command)
+	}
+
+	if errUnmarshal := json.Unmarshal(dataBytes, dst); errUnmarshal != nil {
+		return fmt.Errorf("%w: malformed data at %s actions: %v", contract.ErrInvalidRequest, msg.Command, errUnmarshal)
 	}
+	return nil
+}
 
-	return "", nil
+// vehicleOf describe the car attribute given on park, e.g. `White Toyota Avanza`
+func vehicleOf(car types.Car) string {
+	parts := []string{}
+	for _, attribute := range []string{car.Color, car.Make, car.Model} {
+		if attribute != "" {
+			parts = append(parts, attribute)
+		}
+	}
+	return strings.Join(parts, " ")
 }
@@ -0,0 +1,692 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/khafidprayoga/parking-app/internal/wire"
+)
+
+type httpError struct {
+	Code  types.ErrorCode `json:"code,omitempty"`
+	Error string          `json:"error"`
+}
+
+// openLotRequest open lot of capacity car slot, or the typed slot when given
+type openLotRequest struct {
+	Lot      string          `json:"lot,omitempty"`
+	Capacity int             `json:"capacity"`
+	Slots    types.LotLayout `json:"slots,omitempty"`
+}
+
+type resizeLotRequest struct {
+	openLotRequest
+	Drain bool `json:"drain"`
+}
+
+type openLotResponse struct {
+	Lot      string          `json:"lot"`
+	Capacity int             `json:"capacity"`
+	Slots    types.LotLayout `json:"slots"`
+}
+
+type parkResponse struct {
+	AreaNumber   int    `json:"area_number"`
+	PoliceNumber string `json:"police_number"`
+	Class        string `json:"class"`
+}
+
+// HTTPHandler expose the parking use case as REST API, every path take ?lot=name
+// to target named lot (POST /lots take it in the body):
+//
+//	POST /lots                 {"lot": "north", "capacity": 6} or {"slots": [{"class": "car", "count": 20}, {"class": "moto", "count": 10}]}
+//	PATCH /lots                {"lot": "north", "capacity": 8, "drain": true}
+//	POST /cars                 {"police_number": "KA-01-HH-1234", "class": "moto", "color": "white", "make": "Toyota", "model": "Avanza", "photo": "anpr/0001.jpg"}
+//	GET  /cars?color=white&make=Toyota&class=car (filter optional)
+//	GET  /cars/{plate}
+//	POST /cars/{plate}/leave   {"hours": 2, "method": "card"} (optional)
+//	GET  /payments?plate=B1234ABC&method=card (filter optional)
+//	POST /payments/{id}/refund {"amount": 5, "reason": "wrong slot"} (amount optional)
+//	POST /payments/{id}/void   {"reason": "duplicate charge"}
+//	GET  /slots/{n}
+//	POST /slots/{n}/disable    {"reason": "cleaning", "force": false}
+//	POST /slots/{n}/enable
+//	POST /reservations         {"police_number": "B1234ABC", "from": "2024-05-01T09:00:00Z", "to": "2024-05-01T12:00:00Z", "slot": 3}
+//	DELETE /reservations/{plate}
+//	POST /members              {"police_number": "B1234ABC", "name": "Budi", "months": 3} (months optional)
+//	GET  /members
+//	DELETE /members/{plate}
+//	GET  /status?level=2&zone=B        (filter optional)
+//	GET  /history?plate=B1234ABC&from=2024-05-01&to=2024-05-02 (filter optional)
+//	GET  /report?period=hourly&from=2024-05-01&to=2024-05-02     (period daily, range optional)
+func (srv *ParkingAppServer) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lots", srv.httpLots)
+	mux.HandleFunc("/cars", srv.httpCars)
+	mux.HandleFunc("/cars/", srv.httpCar)
+	mux.HandleFunc("/slots/", srv.httpSlot)
+	mux.HandleFunc("/reservations", srv.httpReserve)
+	mux.HandleFunc("/reservations/", srv.httpCancelReservation)
+	mux.HandleFunc("/payments", srv.httpPayments)
+	mux.HandleFunc("/payments/", srv.httpAdjustPayment)
+	mux.HandleFunc("/members", srv.httpMembers)
+	mux.HandleFunc("/members/", srv.httpRemoveMember)
+	mux.HandleFunc("/status", srv.httpStatus)
+	mux.HandleFunc("/history", srv.httpHistory)
+	mux.HandleFunc("/report", srv.httpReport)
+	return mux
+}
+
+// httpLots open the lot on POST and resize it on PATCH
+func (srv *ParkingAppServer) httpLots(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPatch {
+		srv.httpResizeLot(w, r)
+		return
+	}
+	srv.httpOpenLot(w, r)
+}
+
+func (srv *ParkingAppServer) httpResizeLot(w http.ResponseWriter, r *http.Request) {
+	req := resizeLotRequest{}
+	if errDecode := decodeBody(w, r, &req); errDecode != nil {
+		writeJSON(w, http.StatusBadRequest, httpError{Code: types.ErrCodeInvalidRequest, Error: fmt.Sprintf("invalid request body: %v", errDecode)})
+		return
+	}
+
+	layout := req.Slots
+	if len(layout) == 0 {
+		layout = types.UniformLayout(req.Capacity)
+	}
+
+	service, errLot := srv.lot(req.Lot)
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	if errResize := service.ResizeParkingArea(layout, req.Drain); errResize != nil {
+		writeError(w, errResize)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, openLotResponse{Lot: lotName(req.Lot), Capacity: layout.Capacity(), Slots: layout})
+}
+
+func (srv *ParkingAppServer) httpOpenLot(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	req := openLotRequest{}
+	if errDecode := decodeBody(w, r, &req); errDecode != nil {
+		writeJSON(w, http.StatusBadRequest, httpError{Code: types.ErrCodeInvalidRequest, Error: fmt.Sprintf("invalid request body: %v", errDecode)})
+		return
+	}
+
+	layout := req.Slots
+	if len(layout) == 0 {
+		layout = types.UniformLayout(req.Capacity)
+	}
+
+	if errOpen := srv.openLot(req.Lot, layout); errOpen != nil {
+		writeError(w, errOpen)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, openLotResponse{Lot: lotName(req.Lot), Capacity: layout.Capacity(), Slots: layout})
+}
+
+// httpCars search the parked car on GET and park the car on POST
+func (srv *ParkingAppServer) httpCars(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		srv.httpSearchCars(w, r)
+		return
+	}
+	srv.httpPark(w, r)
+}
+
+func (srv *ParkingAppServer) httpSearchCars(w http.ResponseWriter, r *http.Request) {
+	filter := types.CarFilter{
+		Color: r.URL.Query().Get("color"),
+		Make:  r.URL.Query().Get("make"),
+		Class: r.URL.Query().Get("class"),
+	}
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	cars, errSearch := service.SearchCars(filter)
+	if errSearch != nil {
+		writeError(w, errSearch)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cars)
+}
+
+func (srv *ParkingAppServer) httpPark(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	req := types.CarDTO{}
+	if errDecode := decodeBody(w, r, &req); errDecode != nil {
+		writeJSON(w, http.StatusBadRequest, httpError{Code: types.ErrCodeInvalidRequest, Error: fmt.Sprintf("invalid request body: %v", errDecode)})
+		return
+	}
+	req.RequestId = requestId(r)
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	areaId, errParking := service.EnterArea(req)
+	if errParking != nil {
+		writeError(w, errParking)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, parkResponse{
+		AreaNumber:   areaId,
+		PoliceNumber: req.GetPoliceNumber(),
+		Class:        req.GetClass(),
+	})
+}
+
+// httpCar route /cars/{plate} and /cars/{plate}/leave
+func (srv *ParkingAppServer) httpCar(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/cars/"), "/")
+	switch {
+	case parts[0] == "":
+		writeJSON(w, http.StatusNotFound, httpError{Error: "not found"})
+	case len(parts) == 1:
+		srv.httpFindCar(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "leave":
+		srv.httpLeave(w, r, parts[0])
+	default:
+		writeJSON(w, http.StatusNotFound, httpError{Error: "not found"})
+	}
+}
+
+func (srv *ParkingAppServer) httpFindCar(w http.ResponseWriter, r *http.Request, plate string) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	car, errFind := service.FindCar(plate)
+	if errFind != nil {
+		writeError(w, errFind)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, car)
+}
+
+func (srv *ParkingAppServer) httpLeave(w http.ResponseWriter, r *http.Request, plate string) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	// body is optional, without hours the real elapsed time is billed
+	req := types.CarDTO{}
+	if errDecode := decodeBody(w, r, &req); errDecode != nil && errDecode != io.EOF {
+		writeJSON(w, http.StatusBadRequest, httpError{Code: types.ErrCodeInvalidRequest, Error: fmt.Sprintf("invalid request body: %v", errDecode)})
+		return
+	}
+	req.RequestId = requestId(r)
+	req.PoliceNumber = plate
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	exitedCar, errLeave := service.LeaveArea(req)
+	if errLeave != nil {
+		writeError(w, errLeave)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, exitedCar)
+}
+
+func (srv *ParkingAppServer) httpSlot(w http.ResponseWriter, r *http.Request) {
+	// only /slots/{n}, /slots/{n}/disable and /slots/{n}/enable live under this prefix
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/slots/"), "/")
+	if len(parts) > 2 || (len(parts) == 2 && parts[1] != "disable" && parts[1] != "enable") {
+		writeJSON(w, http.StatusNotFound, httpError{Error: "not found"})
+		return
+	}
+
+	method := http.MethodPost
+	if len(parts) == 1 {
+		method = http.MethodGet
+	}
+
+	if !allowMethod(w, r, method) {
+		return
+	}
+
+	number, errCv := strconv.Atoi(parts[0])
+	if errCv != nil {
+		writeJSON(w, http.StatusBadRequest, httpError{Code: types.ErrCodeInvalidRequest, Error: fmt.Sprintf("invalid slot number %s", parts[0])})
+		return
+	}
+
+	if len(parts) == 1 {
+		srv.httpSlotDetail(w, r, number)
+		return
+	}
+
+	// body is optional, it only carry the disable reason
+	req := types.SlotDTO{}
+	if errDecode := decodeBody(w, r, &req); errDecode != nil && errDecode != io.EOF {
+		writeJSON(w, http.StatusBadRequest, httpError{Code: types.ErrCodeInvalidRequest, Error: fmt.Sprintf("invalid request body: %v", errDecode)})
+		return
+	}
+	req.Number = number
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	var errSlot error
+	if parts[1] == "enable" {
+		errSlot = service.EnableSlot(req.Number)
+	} else {
+		errSlot = service.DisableSlot(req.Number, req.Reason, req.Force)
+	}
+	if errSlot != nil {
+		writeError(w, errSlot)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, req)
+}
+
+func (srv *ParkingAppServer) httpReserve(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	req := types.ReservationDTO{}
+	if errDecode := decodeBody(w, r, &req); errDecode != nil {
+		writeJSON(w, http.StatusBadRequest, httpError{Code: types.ErrCodeInvalidRequest, Error: fmt.Sprintf("invalid request body: %v", errDecode)})
+		return
+	}
+	req.RequestId = requestId(r)
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	reservation, errReserve := service.Reserve(req)
+	if errReserve != nil {
+		writeError(w, errReserve)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, reservation)
+}
+
+func (srv *ParkingAppServer) httpCancelReservation(w http.ResponseWriter, r *http.Request) {
+	// only /reservations/{plate} live under this prefix
+	plate := strings.TrimPrefix(r.URL.Path, "/reservations/")
+	if plate == "" || strings.Contains(plate, "/") {
+		writeJSON(w, http.StatusNotFound, httpError{Error: "not found"})
+		return
+	}
+
+	if !allowMethod(w, r, http.MethodDelete) {
+		return
+	}
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	if errCancel := service.CancelReservation(plate); errCancel != nil {
+		writeError(w, errCancel)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (srv *ParkingAppServer) httpPayments(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	query := types.PaymentQuery{
+		PoliceNumber: r.URL.Query().Get("plate"),
+		Method:       r.URL.Query().Get("method"),
+	}
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	report, errReport := service.Payments(query)
+	if errReport != nil {
+		writeError(w, errReport)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// httpAdjustPayment route /payments/{id}/refund and /payments/{id}/void
+func (srv *ParkingAppServer) httpAdjustPayment(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/payments/"), "/")
+	if len(parts) != 2 || parts[0] == "" || (parts[1] != types.EntryRefund && parts[1] != types.EntryVoid) {
+		writeJSON(w, http.StatusNotFound, httpError{Error: "not found"})
+		return
+	}
+
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	// body is optional, without amount everything remaining is refunded
+	req := types.RefundDTO{}
+	if errDecode := decodeBody(w, r, &req); errDecode != nil && errDecode != io.EOF {
+		writeJSON(w, http.StatusBadRequest, httpError{Code: types.ErrCodeInvalidRequest, Error: fmt.Sprintf("invalid request body: %v", errDecode)})
+		return
+	}
+	req.RequestId = requestId(r)
+	req.PaymentId = parts[0]
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	adjust := service.Refund
+	if parts[1] == types.EntryVoid {
+		adjust = service.Void
+	}
+
+	payment, errAdjust := adjust(req)
+	if errAdjust != nil {
+		writeError(w, errAdjust)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, payment)
+}
+
+// httpMembers list the member on GET and register the monthly pass on POST
+func (srv *ParkingAppServer) httpMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		srv.httpListMembers(w, r)
+		return
+	}
+	srv.httpAddMember(w, r)
+}
+
+func (srv *ParkingAppServer) httpListMembers(w http.ResponseWriter, r *http.Request) {
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	members, errList := service.Members()
+	if errList != nil {
+		writeError(w, errList)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, members)
+}
+
+func (srv *ParkingAppServer) httpAddMember(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	req := types.MemberDTO{}
+	if errDecode := decodeBody(w, r, &req); errDecode != nil {
+		writeJSON(w, http.StatusBadRequest, httpError{Code: types.ErrCodeInvalidRequest, Error: fmt.Sprintf("invalid request body: %v", errDecode)})
+		return
+	}
+	req.RequestId = requestId(r)
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	member, errMember := service.AddMember(req)
+	if errMember != nil {
+		writeError(w, errMember)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, member)
+}
+
+func (srv *ParkingAppServer) httpRemoveMember(w http.ResponseWriter, r *http.Request) {
+	// only /members/{plate} live under this prefix
+	plate := strings.TrimPrefix(r.URL.Path, "/members/")
+	if plate == "" || strings.Contains(plate, "/") {
+		writeJSON(w, http.StatusNotFound, httpError{Error: "not found"})
+		return
+	}
+
+	if !allowMethod(w, r, http.MethodDelete) {
+		return
+	}
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	if errRemove := service.RemoveMember(plate); errRemove != nil {
+		writeError(w, errRemove)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (srv *ParkingAppServer) httpSlotDetail(w http.ResponseWriter, r *http.Request, number int) {
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	detail, errSlot := service.SlotDetail(number)
+	if errSlot != nil {
+		writeError(w, errSlot)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, detail)
+}
+
+func (srv *ParkingAppServer) httpStatus(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	filter := types.StatusFilter{Zone: r.URL.Query().Get("zone")}
+	if strLevel := r.URL.Query().Get("level"); strLevel != "" {
+		level, errCv := strconv.Atoi(strLevel)
+		if errCv != nil {
+			writeJSON(w, http.StatusBadRequest, httpError{Code: types.ErrCodeInvalidRequest, Error: fmt.Sprintf("invalid level %s", strLevel)})
+			return
+		}
+		filter.Level = level
+	}
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	status, errGetStatus := service.Status()
+	if errGetStatus != nil {
+		writeError(w, errGetStatus)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status.Filter(filter))
+}
+
+func (srv *ParkingAppServer) httpHistory(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	query := types.HistoryQuery{PoliceNumber: r.URL.Query().Get("plate")}
+	if errRange := timeRangeOf(r, &query.From, &query.To); errRange != nil {
+		writeJSON(w, http.StatusBadRequest, httpError{Code: types.ErrCodeInvalidRequest, Error: errRange.Error()})
+		return
+	}
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	sessions, errHistory := service.History(query)
+	if errHistory != nil {
+		writeError(w, errHistory)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+func (srv *ParkingAppServer) httpReport(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	query := types.ReportQuery{Period: r.URL.Query().Get("period")}
+	if errRange := timeRangeOf(r, &query.From, &query.To); errRange != nil {
+		writeJSON(w, http.StatusBadRequest, httpError{Code: types.ErrCodeInvalidRequest, Error: errRange.Error()})
+		return
+	}
+
+	service, errLot := srv.lot(lotOf(r))
+	if errLot != nil {
+		writeError(w, errLot)
+		return
+	}
+
+	report, errReport := service.Report(query)
+	if errReport != nil {
+		writeError(w, errReport)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// timeRangeOf read the optional `from` and `to` query time
+func timeRangeOf(r *http.Request, from, to *time.Time) error {
+	for _, bound := range []struct {
+		key string
+		dst *time.Time
+	}{{"from", from}, {"to", to}} {
+		strTime := r.URL.Query().Get(bound.key)
+		if strTime == "" {
+			continue
+		}
+
+		at, errParse := types.ParseTime(strTime)
+		if errParse != nil {
+			return errParse
+		}
+		*bound.dst = at
+	}
+	return nil
+}
+
+// decodeBody decode the json request body, capped at the socket frame size
+func decodeBody(w http.ResponseWriter, r *http.Request, dst any) error {
+	return json.NewDecoder(http.MaxBytesReader(w, r.Body, wire.MaxFrameSize)).Decode(dst)
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+
+	w.Header().Set("Allow", method)
+	writeJSON(w, http.StatusMethodNotAllowed, httpError{Error: fmt.Sprintf("method %s is not allowed", r.Method)})
+	return false
+}
+
+// lotOf return the target lot of the request, ?lot=name query
+func lotOf(r *http.Request) string {
+	return r.URL.Query().Get("lot")
+}
+
+// requestId reuse the caller request id header when exist, same as x_request_id on the socket
+func requestId(r *http.Request) string {
+	return r.Header.Get("X-Request-Id")
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, httpStatusOf(err), httpError{Code: contract.CodeOf(err), Error: err.Error()})
+}
+
+// httpStatusOf map backend error code into http status code
+func httpStatusOf(err error) int {
+	switch contract.CodeOf(err) {
+	case types.ErrCodeInvalidRequest, types.ErrCodeInvalidDuration:
+		return http.StatusBadRequest
+	case types.ErrCodeNotFound, types.ErrCodeLotNotFound, types.ErrCodeSlotNotFound, types.ErrCodeNoReservation, types.ErrCodeMemberNotFound, types.ErrCodePaymentNotFound:
+		return http.StatusNotFound
+	case types.ErrCodeLotFull, types.ErrCodeSlotOccupied, types.ErrCodeAlreadyParked, types.ErrCodeAlreadyReserved, types.ErrCodePaymentSettled, types.ErrCodeAlreadyInitialized, types.ErrCodeNotInitialized:
+		return http.StatusConflict
+	case types.ErrCodeStorageUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
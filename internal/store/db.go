@@ -1 +1,57 @@
 package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+// Store persist the parking lot state so the server can pick up where it left after restart.
+// Every mutation is appended into the write-ahead log, and periodically compacted into a snapshot.
+type Store interface {
+	// Load return the last saved snapshot (nil when nothing has been saved yet)
+	// and the log entries written after it, in sequence order
+	Load() (state *Snapshot, entries []Entry, err error)
+
+	// Append durably write single entry into the log before it is acknowledged
+	Append(entry Entry) error
+
+	// Save replace the snapshot and drop every log entry already covered by it
+	Save(state Snapshot) error
+
+	Close() error
+}
+
+// Snapshot is the full state of single parking lot backend
+type Snapshot struct {
+	// Seq is the last log entry sequence applied into this snapshot
+	Seq uint64 `json:"seq"`
+
+	LotCapacity int            `json:"lot_capacity"`
+	CarList     []*types.Car   `json:"car_list"`
+	Slots       []types.Slot   `json:"slots,omitempty"`
+	Revenue     money.Money    `json:"revenue"`
+	Tx          map[string]int `json:"tx"`
+
+	// Sessions is the completed parking history
+	Sessions []types.Session `json:"sessions,omitempty"`
+
+	// Members is the monthly pass per plate key
+	Members map[string]types.Member `json:"members,omitempty"`
+
+	// Ledger is every payment, refund and void, Revenue is only kept for older reader
+	Ledger types.Ledger `json:"ledger,omitempty"`
+
+	// Currency every amount is priced in, state written before it has plain amount
+	Currency string `json:"currency,omitempty"`
+}
+
+// Entry is single mutation recorded in the write-ahead log
+type Entry struct {
+	Seq  uint64          `json:"seq"`
+	Op   string          `json:"op"`
+	At   time.Time       `json:"at"`
+	Data json.RawMessage `json:"data"`
+}
@@ -0,0 +1,230 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	snapshotFileName = "snapshot.json"
+	walFileName      = "wal.log"
+)
+
+// FileStore keep the snapshot as json file and the write-ahead log as append only file inside a data directory
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+	wal *os.File
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if errMkdir := os.MkdirAll(dir, 0o755); errMkdir != nil {
+		return nil, fmt.Errorf("failed to create data directory %s: %v", dir, errMkdir)
+	}
+
+	f := &FileStore{
+		dir: dir,
+	}
+
+	if errOpen := f.openWal(); errOpen != nil {
+		return nil, errOpen
+	}
+	return f, nil
+}
+
+func (f *FileStore) Load() (state *Snapshot, entries []Entry, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dataBytes, errRead := os.ReadFile(f.snapshotPath())
+	switch {
+	case errors.Is(errRead, os.ErrNotExist):
+		// fresh data directory, only the log may exist
+	case errRead != nil:
+		err = fmt.Errorf("failed to read snapshot: %v", errRead)
+		return
+	default:
+		state = &Snapshot{}
+		if errUnmarshal := json.Unmarshal(dataBytes, state); errUnmarshal != nil {
+			err = fmt.Errorf("failed to unmarshal snapshot: %v", errUnmarshal)
+			return nil, nil, err
+		}
+	}
+
+	logEntries, validSize, errReadLog := readWal(f.walPath())
+	if errReadLog != nil {
+		err = errReadLog
+		return nil, nil, err
+	}
+
+	// drop the torn tail left by a crash in the middle of append, it was never acknowledged
+	if errTruncate := f.wal.Truncate(validSize); errTruncate != nil {
+		err = fmt.Errorf("failed to truncate torn log tail: %v", errTruncate)
+		return nil, nil, err
+	}
+
+	for _, entry := range logEntries {
+		if state != nil && entry.Seq <= state.Seq {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return state, entries, nil
+}
+
+// Append write the entry and fsync the log file before return
+func (f *FileStore) Append(entry Entry) (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	line, errEncode := encodeWalEntry(entry)
+	if errEncode != nil {
+		return errEncode
+	}
+
+	if _, errWrite := f.wal.Write(line); errWrite != nil {
+		err = fmt.Errorf("failed to append log entry %d: %v", entry.Seq, errWrite)
+		return
+	}
+
+	if errSync := f.wal.Sync(); errSync != nil {
+		err = fmt.Errorf("failed to sync log entry %d: %v", entry.Seq, errSync)
+		return
+	}
+	return
+}
+
+// Save write the snapshot into temporary file first then rename it,
+// so a crash in the middle of write never leave a half written snapshot.
+// The log is compacted afterward, a crash in between only leave entries that are skipped on load.
+func (f *FileStore) Save(state Snapshot) (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dataBytes, errMarshal := json.Marshal(state)
+	if errMarshal != nil {
+		err = fmt.Errorf("failed to marshal snapshot: %v", errMarshal)
+		return
+	}
+
+	if errWrite := writeFileSync(f.snapshotPath(), dataBytes); errWrite != nil {
+		return errWrite
+	}
+
+	return f.compactWal(state.Seq)
+}
+
+func (f *FileStore) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.wal == nil {
+		return nil
+	}
+
+	errClose := f.wal.Close()
+	f.wal = nil
+	if errClose != nil {
+		return fmt.Errorf("failed to close log file: %v", errClose)
+	}
+	return nil
+}
+
+// compactWal rewrite the log keeping only entries newer than seq, caller must hold the lock
+func (f *FileStore) compactWal(seq uint64) (err error) {
+	logEntries, _, errRead := readWal(f.walPath())
+	if errRead != nil {
+		return errRead
+	}
+
+	remaining := make([]byte, 0)
+	for _, entry := range logEntries {
+		if entry.Seq <= seq {
+			continue
+		}
+
+		line, errEncode := encodeWalEntry(entry)
+		if errEncode != nil {
+			return errEncode
+		}
+		remaining = append(remaining, line...)
+	}
+
+	if errWrite := writeFileSync(f.walPath(), remaining); errWrite != nil {
+		return errWrite
+	}
+
+	// the old handle still point to replaced file
+	_ = f.wal.Close()
+	return f.openWal()
+}
+
+func (f *FileStore) openWal() error {
+	wal, errOpen := os.OpenFile(f.walPath(), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if errOpen != nil {
+		return fmt.Errorf("failed to open log file: %v", errOpen)
+	}
+
+	f.wal = wal
+	return nil
+}
+
+func (f *FileStore) snapshotPath() string {
+	return filepath.Join(f.dir, snapshotFileName)
+}
+
+func (f *FileStore) walPath() string {
+	return filepath.Join(f.dir, walFileName)
+}
+
+func writeFileSync(path string, data []byte) (err error) {
+	tmpPath := path + ".tmp"
+
+	file, errOpen := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if errOpen != nil {
+		err = fmt.Errorf("failed to open %s: %v", tmpPath, errOpen)
+		return
+	}
+
+	if _, errWrite := file.Write(data); errWrite != nil {
+		_ = file.Close()
+		err = fmt.Errorf("failed to write %s: %v", tmpPath, errWrite)
+		return
+	}
+
+	if errSync := file.Sync(); errSync != nil {
+		_ = file.Close()
+		err = fmt.Errorf("failed to sync %s: %v", tmpPath, errSync)
+		return
+	}
+
+	if errClose := file.Close(); errClose != nil {
+		err = fmt.Errorf("failed to close %s: %v", tmpPath, errClose)
+		return
+	}
+
+	if errRename := os.Rename(tmpPath, path); errRename != nil {
+		err = fmt.Errorf("failed to replace %s: %v", path, errRename)
+		return
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir flush the directory entry so the rename itself is durable
+func syncDir(dir string) error {
+	d, errOpen := os.Open(dir)
+	if errOpen != nil {
+		return fmt.Errorf("failed to open directory %s: %v", dir, errOpen)
+	}
+	defer d.Close()
+
+	// some platform (windows) does not support fsync on directory
+	_ = d.Sync()
+	return nil
+}
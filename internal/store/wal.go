@@ -0,0 +1,82 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+)
+
+// encodeWalEntry format single log line as `<crc32 hex> <json>\n`,
+// the checksum detect the torn write of the last entry after crash
+func encodeWalEntry(entry Entry) ([]byte, error) {
+	payload, errMarshal := json.Marshal(entry)
+	if errMarshal != nil {
+		return nil, fmt.Errorf("failed to marshal log entry %d: %v", entry.Seq, errMarshal)
+	}
+
+	line := []byte(fmt.Sprintf("%08x ", crc32.ChecksumIEEE(payload)))
+	line = append(line, payload...)
+	line = append(line, '\n')
+	return line, nil
+}
+
+func decodeWalEntry(line []byte) (entry Entry, ok bool) {
+	if len(line) < 10 || line[8] != ' ' {
+		return
+	}
+
+	var checksum uint32
+	if _, errScan := fmt.Sscanf(string(line[:8]), "%08x", &checksum); errScan != nil {
+		return
+	}
+
+	payload := line[9:]
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return
+	}
+
+	if errUnmarshal := json.Unmarshal(payload, &entry); errUnmarshal != nil {
+		return
+	}
+	return entry, true
+}
+
+// readWal return every valid entry and the byte size they occupy,
+// an invalid last line is a torn write and ignored, invalid line in the middle is corruption
+func readWal(path string) (entries []Entry, validSize int64, err error) {
+	dataBytes, errRead := os.ReadFile(path)
+	if errors.Is(errRead, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if errRead != nil {
+		err = fmt.Errorf("failed to read log file: %v", errRead)
+		return
+	}
+
+	offset := 0
+	for offset < len(dataBytes) {
+		end := bytes.IndexByte(dataBytes[offset:], '\n')
+		if end < 0 {
+			// incomplete last line
+			break
+		}
+
+		line := dataBytes[offset : offset+end]
+		entry, ok := decodeWalEntry(line)
+		if !ok {
+			if offset+end+1 < len(dataBytes) {
+				err = fmt.Errorf("corrupted log entry at byte %d", offset)
+				return nil, 0, err
+			}
+			break
+		}
+
+		entries = append(entries, entry)
+		offset += end + 1
+	}
+
+	return entries, int64(offset), nil
+}
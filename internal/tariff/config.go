@@ -0,0 +1,149 @@
+package tariff
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/internal/money"
+)
+
+// Config is the rule set loaded from tariff file, zero value rule is disabled. Fee is plain
+// amount, e.g. 2.50, priced in the currency of the server it is loaded into.
+type Config struct {
+	// BaseFee is charged once and cover the first BaseHours hours
+	BaseFee   money.Money `json:"base_fee"`
+	BaseHours int         `json:"base_hours"`
+
+	// HourlyRate is charged for every started hour after the base hours
+	HourlyRate money.Money `json:"hourly_rate"`
+
+	// WeekendHourlyRate replace HourlyRate for hour started on saturday or sunday
+	WeekendHourlyRate money.Money `json:"weekend_hourly_rate"`
+
+	// FreeMinutes make session not longer than it free of charge
+	FreeMinutes int `json:"free_minutes"`
+
+	// DailyCap is the maximum charged for every 24 hours since entry
+	DailyCap money.Money `json:"daily_cap"`
+
+	// Night charge single flat fee for all hour started inside the night window
+	Night *NightConfig `json:"night"`
+
+	// Timezone used to decide night and weekend, e.g. Asia/Jakarta (default server local time)
+	Timezone string `json:"timezone"`
+
+	// Rounding applied to the elapsed duration before priced
+	Rounding *RoundingConfig `json:"rounding"`
+
+	// Loyalty discount the cost of plate with enough completed session, applied by the backend
+	Loyalty Loyalty `json:"loyalty"`
+}
+
+type NightConfig struct {
+	Start   string      `json:"start"`
+	End     string      `json:"end"`
+	FlatFee money.Money `json:"flat_fee"`
+}
+
+// LoadFile read json tariff config, e.g.
+//
+//	{"base_fee": 10, "base_hours": 2, "hourly_rate": 10, "free_minutes": 15, "daily_cap": 80,
+//	 "weekend_hourly_rate": 15, "night": {"start": "22:00", "end": "06:00", "flat_fee": 20},
+//	 "rounding": {"unit_minutes": 5, "mode": "nearest"}, "loyalty": [{"visits": 5, "percent": 10}]}
+func LoadFile(path string) (rule *RuleSet, err error) {
+	dataBytes, errRead := os.ReadFile(path)
+	if errRead != nil {
+		err = fmt.Errorf("failed to read tariff file: %v", errRead)
+		return
+	}
+
+	cfg := Config{}
+	if errUnmarshal := json.Unmarshal(dataBytes, &cfg); errUnmarshal != nil {
+		err = fmt.Errorf("failed to unmarshal tariff file %s: %v", path, errUnmarshal)
+		return
+	}
+
+	return NewRuleSet(cfg)
+}
+
+func NewRuleSet(cfg Config) (rule *RuleSet, err error) {
+	fees := []money.Money{cfg.BaseFee, cfg.HourlyRate, cfg.WeekendHourlyRate, cfg.DailyCap}
+	if cfg.Night != nil {
+		fees = append(fees, cfg.Night.FlatFee)
+	}
+	for _, fee := range fees {
+		if fee.Currency != "" {
+			err = fmt.Errorf("tariff fee %v must be plain amount, it is priced in the server currency", fee)
+			return
+		}
+	}
+
+	if cfg.BaseFee.Sign() < 0 || cfg.HourlyRate.Sign() < 0 || cfg.WeekendHourlyRate.Sign() < 0 || cfg.DailyCap.Sign() < 0 {
+		err = fmt.Errorf("tariff fee must not be negative")
+		return
+	}
+
+	if cfg.BaseHours < 0 || cfg.FreeMinutes < 0 {
+		err = fmt.Errorf("tariff base hours and free minutes must not be negative")
+		return
+	}
+
+	if cfg.BaseFee.Sign() > 0 && cfg.BaseHours == 0 {
+		err = fmt.Errorf("tariff base fee need base hours it cover, at least 1")
+		return
+	}
+
+	rule = &RuleSet{
+		cfg:      cfg,
+		location: time.Local,
+	}
+
+	if rule.rounding, err = newRounding(cfg.Rounding); err != nil {
+		return nil, err
+	}
+
+	if rule.cfg.Loyalty, err = newLoyalty(cfg.Loyalty); err != nil {
+		return nil, err
+	}
+
+	if cfg.Timezone != "" {
+		location, errLoc := time.LoadLocation(cfg.Timezone)
+		if errLoc != nil {
+			err = fmt.Errorf("invalid tariff timezone %s: %v", cfg.Timezone, errLoc)
+			return nil, err
+		}
+		rule.location = location
+	}
+
+	if cfg.Night != nil {
+		if cfg.Night.FlatFee.Sign() < 0 {
+			err = fmt.Errorf("tariff night flat fee must not be negative")
+			return nil, err
+		}
+
+		if rule.nightStart, err = parseClock(cfg.Night.Start); err != nil {
+			return nil, err
+		}
+		if rule.nightEnd, err = parseClock(cfg.Night.End); err != nil {
+			return nil, err
+		}
+		if rule.nightStart == rule.nightEnd {
+			err = fmt.Errorf("tariff night window must not be empty")
+			return nil, err
+		}
+	}
+
+	return rule, nil
+}
+
+// parseClock convert HH:MM into minute of the day
+func parseClock(clock string) (minute int, err error) {
+	at, errParse := time.Parse("15:04", clock)
+	if errParse != nil {
+		err = fmt.Errorf("invalid tariff clock %s, expected HH:MM", clock)
+		return
+	}
+	return at.Hour()*60 + at.Minute(), nil
+}
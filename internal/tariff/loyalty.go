@@ -0,0 +1,43 @@
+package tariff
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LoyaltyTier discount Percent of the cost for plate with at least Visits completed session
+type LoyaltyTier struct {
+	Visits  int     `json:"visits"`
+	Percent float64 `json:"percent"`
+}
+
+// Loyalty is the tier list, the highest tier reached by the plate is applied
+type Loyalty []LoyaltyTier
+
+// TierOf return the highest tier reached by the completed visits count
+func (l Loyalty) TierOf(visits int) (tier LoyaltyTier, ok bool) {
+	for _, candidate := range l {
+		if visits >= candidate.Visits && (!ok || candidate.Visits > tier.Visits) {
+			tier, ok = candidate, true
+		}
+	}
+	return
+}
+
+func newLoyalty(tiers Loyalty) (Loyalty, error) {
+	sorted := append(Loyalty(nil), tiers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Visits < sorted[j].Visits })
+
+	for i, tier := range sorted {
+		if tier.Visits < 1 {
+			return nil, fmt.Errorf("tariff loyalty visits must be positive")
+		}
+		if tier.Percent <= 0 || tier.Percent > 100 {
+			return nil, fmt.Errorf("tariff loyalty percent must be between 0 and 100")
+		}
+		if i > 0 && sorted[i-1].Visits == tier.Visits {
+			return nil, fmt.Errorf("tariff loyalty has duplicate tier for %d visits", tier.Visits)
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,69 @@
+package tariff
+
+import (
+	"fmt"
+	"time"
+)
+
+// rounding mode of the parking duration before it is priced
+const (
+	RoundUp      = "up"
+	RoundDown    = "down"
+	RoundNearest = "nearest"
+)
+
+// RoundingConfig round the parking duration into multiple of UnitMinutes, zero unit disable rounding
+type RoundingConfig struct {
+	UnitMinutes int    `json:"unit_minutes"`
+	Mode        string `json:"mode"`
+}
+
+type rounding struct {
+	unit time.Duration
+	mode string
+}
+
+func newRounding(cfg *RoundingConfig) (r rounding, err error) {
+	if cfg == nil || cfg.UnitMinutes == 0 {
+		return
+	}
+
+	if cfg.UnitMinutes < 0 {
+		err = fmt.Errorf("tariff rounding unit must not be negative")
+		return
+	}
+
+	mode := cfg.Mode
+	switch mode {
+	case RoundUp, RoundDown, RoundNearest:
+	case "":
+		mode = RoundUp
+	default:
+		err = fmt.Errorf("invalid tariff rounding mode %s, expected %s, %s or %s", mode, RoundUp, RoundDown, RoundNearest)
+		return
+	}
+
+	return rounding{
+		unit: time.Duration(cfg.UnitMinutes) * time.Minute,
+		mode: mode,
+	}, nil
+}
+
+func (r rounding) apply(d time.Duration) time.Duration {
+	if r.unit == 0 {
+		return d
+	}
+
+	switch r.mode {
+	case RoundDown:
+		return d.Truncate(r.unit)
+	case RoundNearest:
+		return d.Round(r.unit)
+	default:
+		rounded := d.Truncate(r.unit)
+		if rounded < d {
+			rounded += r.unit
+		}
+		return rounded
+	}
+}
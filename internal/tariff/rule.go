@@ -0,0 +1,112 @@
+package tariff
+
+import (
+	"math"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/internal/money"
+)
+
+// RuleSet is configurable Tariff combining base fee, hourly rate,
+// free period, daily cap, night flat fee and weekend rate
+type RuleSet struct {
+	cfg      Config
+	location *time.Location
+	rounding rounding
+
+	// minute of the day, only used when cfg.Night is set
+	nightStart int
+	nightEnd   int
+}
+
+// Loyalty return the configured loyalty tier, lowest visits first
+func (r *RuleSet) Loyalty() Loyalty {
+	return r.cfg.Loyalty
+}
+
+func (r *RuleSet) Cost(entry, exit time.Time) money.Money {
+	duration := r.rounding.apply(exit.Sub(entry))
+	if r.cfg.FreeMinutes > 0 && duration <= time.Duration(r.cfg.FreeMinutes)*time.Minute {
+		return money.Money{}
+	}
+
+	// every started hour is charged
+	hours := int(math.Ceil(duration.Hours()))
+	if hours < 1 {
+		hours = 1
+	}
+
+	const hoursPerDay = 24
+	var (
+		total        money.Money
+		chargedNight = make(map[string]struct{})
+	)
+
+	for dayStart := 0; dayStart < hours; dayStart += hoursPerDay {
+		dayCost := money.Money{}
+
+		for h := dayStart; h < hours && h < dayStart+hoursPerDay; h++ {
+			dayCost = dayCost.Add(r.hourCost(h, entry.Add(time.Duration(h)*time.Hour), chargedNight))
+		}
+
+		if r.cfg.DailyCap.Sign() > 0 && dayCost.Cmp(r.cfg.DailyCap) > 0 {
+			dayCost = r.cfg.DailyCap
+		}
+		total = total.Add(dayCost)
+	}
+
+	return total
+}
+
+// hourCost price the n-th hour of the session started at
+func (r *RuleSet) hourCost(n int, at time.Time, chargedNight map[string]struct{}) money.Money {
+	if r.cfg.BaseHours > 0 && n < r.cfg.BaseHours {
+		if n == 0 {
+			return r.cfg.BaseFee
+		}
+		return money.Money{}
+	}
+
+	at = at.In(r.location)
+
+	if night, ok := r.nightOf(at); ok {
+		if _, charged := chargedNight[night]; charged {
+			return money.Money{}
+		}
+		chargedNight[night] = struct{}{}
+		return r.cfg.Night.FlatFee
+	}
+
+	if r.cfg.WeekendHourlyRate.Sign() > 0 && (at.Weekday() == time.Saturday || at.Weekday() == time.Sunday) {
+		return r.cfg.WeekendHourlyRate
+	}
+
+	return r.cfg.HourlyRate
+}
+
+// nightOf return the date the night window containing at is started
+func (r *RuleSet) nightOf(at time.Time) (night string, ok bool) {
+	if r.cfg.Night == nil {
+		return
+	}
+
+	minute := at.Hour()*60 + at.Minute()
+	const dateLayout = "2006-01-02"
+
+	// window inside single day, e.g. 00:00 - 06:00
+	if r.nightStart < r.nightEnd {
+		if minute >= r.nightStart && minute < r.nightEnd {
+			return at.Format(dateLayout), true
+		}
+		return
+	}
+
+	// window across midnight, e.g. 22:00 - 06:00
+	switch {
+	case minute >= r.nightStart:
+		return at.Format(dateLayout), true
+	case minute < r.nightEnd:
+		return at.AddDate(0, 0, -1).Format(dateLayout), true
+	}
+	return
+}
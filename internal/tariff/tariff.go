@@ -0,0 +1,22 @@
+package tariff
+
+import (
+	"time"
+
+	"github.com/khafidprayoga/parking-app/internal/money"
+)
+
+// Tariff price single parking session as plain amount, the backend put it in its currency
+type Tariff interface {
+	Cost(entry, exit time.Time) money.Money
+}
+
+// Default is the classic tariff, 10 for the first 2 hours and 10 for every extra hour
+func Default() Tariff {
+	rule, _ := NewRuleSet(Config{
+		BaseFee:    money.FromUnits(10, ""),
+		BaseHours:  2,
+		HourlyRate: money.FromUnits(10, ""),
+	})
+	return rule
+}
@@ -3,6 +3,31 @@ package types
 import "time"
 
 type AppConfig struct {
+	// ConnLifetime is how long connection may stay idle waiting for next request
 	ConnLifetime time.Duration
 	AppVersion   string
+
+	// HTTPAddr is the listen address of rest api, empty mean disabled
+	HTTPAddr string
+
+	// TariffFile is json rule set used to price parking, empty mean the default tariff
+	TariffFile string
+
+	// Currency is the code every ledger amount is recorded in
+	Currency string
+
+	// ReservationGrace is how long reserved slot wait after its start before released as no-show
+	ReservationGrace time.Duration
+
+	// SimulateHours accept the leave hours override, for demo and simulation only
+	SimulateHours bool
+
+	// DataDir is where the parking lot state persisted, empty mean in memory only
+	DataDir string
+
+	// CompactInterval is how often the write-ahead log folded into snapshot
+	CompactInterval time.Duration
+
+	// ShutdownTimeout is how long in flight request waited on shutdown
+	ShutdownTimeout time.Duration
 }
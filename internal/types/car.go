@@ -3,24 +3,115 @@ package types
 import (
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/plate"
 )
 
 type Car struct {
-	Id           string     `json:"id"`
-	AreaNumber   int        `json:"area_number"`
-	Color        string     `json:"color"`
-	PoliceNumber string     `json:"police_number"`
-	ParkingAt    time.Time  `json:"parking_at"`
-	ExitAt       *time.Time `json:"exit_at"`
-	Cost         float64    `json:"cost"`
+	Id           string      `json:"id"`
+	AreaNumber   int         `json:"area_number"`
+	SlotLabel    string      `json:"slot_label,omitempty"`
+	Color        string      `json:"color"`
+	Make         string      `json:"make,omitempty"`
+	Model        string      `json:"model,omitempty"`
+	Photo        string      `json:"photo,omitempty"` // entry or ANPR image reference, e.g. path or url
+	Class        string      `json:"class,omitempty"`
+	PoliceNumber string      `json:"police_number"`
+	ParkingAt    time.Time   `json:"parking_at"`
+	ExitAt       *time.Time  `json:"exit_at"`
+	Cost         money.Money `json:"cost"`
+
+	// Discount is already taken off Cost, e.g. monthly pass or loyalty tier
+	Discount       money.Money `json:"discount"`
+	DiscountReason string      `json:"discount_reason,omitempty"`
+
+	// PaymentId is the ledger payment of Cost, paid with Method
+	PaymentId string `json:"payment_id,omitempty"`
+	Method    string `json:"method,omitempty"`
+}
+
+// GetClass return the vehicle class, car parked before vehicle was classified is car
+func (c Car) GetClass() string {
+	if c.Class == "" {
+		return ClassCar
+	}
+	return c.Class
 }
 
 type CarDTO struct {
 	RequestId    string `json:"request_id"`
 	PoliceNumber string `json:"police_number"`
+	Class        string `json:"class,omitempty"`
 	Hours        int    `json:"hours,omitempty"`
+
+	// Method is the payment method on leave, cash when not specified
+	Method string `json:"method,omitempty"`
+
+	// optional vehicle attribute given on park
+	Color string `json:"color,omitempty"`
+	Make  string `json:"make,omitempty"`
+	Model string `json:"model,omitempty"`
+	Photo string `json:"photo,omitempty"`
 }
 
+// GetPoliceNumber return the display form of the plate, e.g. `B 1234 ABC`,
+// malformed one is only upper cased since it is rejected by the backend
 func (c CarDTO) GetPoliceNumber() string {
-	return strings.ToUpper(c.PoliceNumber)
+	if display, err := plate.Normalize(c.PoliceNumber); err == nil {
+		return display
+	}
+	return strings.ToUpper(strings.TrimSpace(c.PoliceNumber))
+}
+
+// GetClass return the vehicle class, car when not specified
+func (c CarDTO) GetClass() string {
+	if c.Class == "" {
+		return ClassCar
+	}
+	return strings.ToLower(c.Class)
+}
+
+// GetColor return the color capitalized, e.g. `white` → `White`
+func (c CarDTO) GetColor() string {
+	return capitalize(c.Color)
+}
+
+// ColorKey is the identity of the color, so `White`, `white` and ` WHITE ` is the same color
+func ColorKey(color string) string {
+	return strings.ToLower(strings.TrimSpace(color))
+}
+
+// CarFilter select parked car by its attribute, empty field match every car
+type CarFilter struct {
+	Color string `json:"color,omitempty"`
+	Make  string `json:"make,omitempty"`
+	Class string `json:"class,omitempty"`
+}
+
+// Match report whether the car is selected by the filter, compared case-insensitively
+func (f CarFilter) Match(car Car) bool {
+	if f.Color != "" && ColorKey(f.Color) != ColorKey(car.Color) {
+		return false
+	}
+
+	if f.Make != "" && !strings.EqualFold(strings.TrimSpace(f.Make), car.Make) {
+		return false
+	}
+
+	if f.Class != "" && !strings.EqualFold(f.Class, car.GetClass()) {
+		return false
+	}
+	return true
+}
+
+func capitalize(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return s
+	}
+	first, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(first)) + strings.ToLower(s[size:])
 }
@@ -1,10 +1,30 @@
 package types
 
 const (
-	CmdServe       string = "serve"
-	CmdCreateStore string = "create_parking_lot"
-	CmdPark        string = "park"
-	CmdLeave       string = "leave"
-	CmdStatus      string = "status"
-	CmdImport      string = "import"
+	CmdServe             string = "serve"
+	CmdCreateStore       string = "create_parking_lot"
+	CmdResize            string = "resize_parking_lot"
+	CmdDisableSlot       string = "disable_slot"
+	CmdEnableSlot        string = "enable_slot"
+	CmdReserve           string = "reserve"
+	CmdCancelReservation string = "cancel_reservation"
+	CmdAddMember         string = "add_member"
+	CmdRemoveMember      string = "remove_member"
+	CmdMembers           string = "list_members"
+	CmdPark              string = "park"
+	CmdLeave             string = "leave"
+	CmdRefund            string = "refund"
+	CmdVoid              string = "void"
+	CmdPayments          string = "payments"
+	CmdStatus            string = "status"
+	CmdHistory           string = "history"
+	CmdReport            string = "report"
+	CmdExport            string = "export"
+	CmdFind              string = "find"
+	CmdSearch            string = "search"
+	CmdSlot              string = "slot"
+	CmdPlatesByColor     string = "registration_numbers_for_cars_with_colour"
+	CmdSlotsByColor      string = "slot_numbers_for_cars_with_colour"
+	CmdSlotByPlate       string = "slot_number_for_registration_number"
+	CmdImport            string = "import"
 )
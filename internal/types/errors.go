@@ -0,0 +1,25 @@
+package types
+
+// ErrorCode is machine readable failure reason sent to the client
+type ErrorCode = string
+
+const (
+	ErrCodeInvalidRequest     ErrorCode = "INVALID_REQUEST"
+	ErrCodeInvalidDuration    ErrorCode = "INVALID_DURATION"
+	ErrCodeNotInitialized     ErrorCode = "NOT_INITIALIZED"
+	ErrCodeAlreadyInitialized ErrorCode = "ALREADY_INITIALIZED"
+	ErrCodeLotFull            ErrorCode = "LOT_FULL"
+	ErrCodeAlreadyParked      ErrorCode = "ALREADY_PARKED"
+	ErrCodeNotFound           ErrorCode = "NOT_FOUND"
+	ErrCodeLotNotFound        ErrorCode = "LOT_NOT_FOUND"
+	ErrCodeSlotOccupied       ErrorCode = "SLOT_OCCUPIED"
+	ErrCodeSlotNotFound       ErrorCode = "SLOT_NOT_FOUND"
+	ErrCodeAlreadyReserved    ErrorCode = "ALREADY_RESERVED"
+	ErrCodeNoReservation      ErrorCode = "RESERVATION_NOT_FOUND"
+	ErrCodeMemberNotFound     ErrorCode = "MEMBER_NOT_FOUND"
+	ErrCodePaymentNotFound    ErrorCode = "PAYMENT_NOT_FOUND"
+	ErrCodePaymentSettled     ErrorCode = "PAYMENT_SETTLED"
+	ErrCodeStorageUnavailable ErrorCode = "STORAGE_UNAVAILABLE"
+	ErrCodeFrameTooLarge      ErrorCode = "FRAME_TOO_LARGE"
+	ErrCodeInternal           ErrorCode = "INTERNAL"
+)
@@ -1,8 +1,54 @@
 package types
 
+import (
+	"strings"
+
+	"github.com/khafidprayoga/parking-app/internal/money"
+)
+
 type AppStatus struct {
-	Revenue            float64 `json:"revenue"`
-	LotParkingCapacity int     `json:"area_capacity"`
-	TxCount            int     `json:"tx_count"`
-	CarList            []*Car  `json:"car_list"`
+	Revenue            money.Money `json:"revenue"`
+	LotParkingCapacity int         `json:"area_capacity"`
+	TxCount            int         `json:"tx_count"`
+	CarList            []*Car      `json:"car_list"`
+
+	// Slots is aligned with CarList, Occupancy is per slot class
+	Slots     []Slot           `json:"slots"`
+	Occupancy []ClassOccupancy `json:"occupancy"`
+}
+
+// StatusFilter narrow the status to one level and/or zone, zero value keep every slot
+type StatusFilter struct {
+	Level int    `json:"level,omitempty"`
+	Zone  string `json:"zone,omitempty"`
+}
+
+func (f StatusFilter) match(slot Slot) bool {
+	if f.Level > 0 && slot.Level != f.Level {
+		return false
+	}
+	if f.Zone != "" && !strings.EqualFold(slot.Zone, f.Zone) {
+		return false
+	}
+	return true
+}
+
+// Filter keep only the slot matching f, revenue and transaction stay lot wide
+func (s AppStatus) Filter(f StatusFilter) AppStatus {
+	if f == (StatusFilter{}) {
+		return s
+	}
+
+	filtered := s
+	filtered.CarList = []*Car{}
+	filtered.Slots = []Slot{}
+	for i, slot := range s.Slots {
+		if f.match(slot) {
+			filtered.Slots = append(filtered.Slots, slot)
+			filtered.CarList = append(filtered.CarList, s.CarList[i])
+		}
+	}
+
+	filtered.Occupancy = OccupancyOf(filtered.Slots, filtered.CarList)
+	return filtered
 }
@@ -0,0 +1,108 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/plate"
+)
+
+// Session is completed parking of one vehicle, recorded when it leave
+type Session struct {
+	// RequestId is the request id the vehicle was parked with
+	RequestId    string      `json:"request_id"`
+	PoliceNumber string      `json:"police_number"`
+	Class        string      `json:"class"`
+	Slot         int         `json:"slot"`
+	SlotLabel    string      `json:"slot_label,omitempty"`
+	EntryAt      time.Time   `json:"entry_at"`
+	ExitAt       time.Time   `json:"exit_at"`
+	Cost         money.Money `json:"cost"`
+
+	// Discount is already taken off Cost
+	Discount       money.Money `json:"discount"`
+	DiscountReason string      `json:"discount_reason,omitempty"`
+
+	PaymentId string `json:"payment_id,omitempty"`
+	Method    string `json:"method,omitempty"`
+}
+
+// SessionOf record the exited car as completed session
+func SessionOf(car Car) Session {
+	session := Session{
+		RequestId:    car.Id,
+		PoliceNumber: car.PoliceNumber,
+		Class:        car.GetClass(),
+		Slot:         car.AreaNumber,
+		SlotLabel:    car.SlotLabel,
+		EntryAt:      car.ParkingAt,
+		Cost:         car.Cost,
+
+		Discount:       car.Discount,
+		DiscountReason: car.DiscountReason,
+
+		PaymentId: car.PaymentId,
+		Method:    car.Method,
+	}
+	if car.ExitAt != nil {
+		session.ExitAt = *car.ExitAt
+	}
+	return session
+}
+
+// Duration is how long the vehicle was parked
+func (s Session) Duration() time.Duration {
+	return s.ExitAt.Sub(s.EntryAt)
+}
+
+// Receipt render the session as printable receipt
+func (s Session) Receipt() string {
+	lines := []string{
+		"PARKING RECEIPT",
+		fmt.Sprintf("plate    : %s (%s)", s.PoliceNumber, s.Class),
+		fmt.Sprintf("slot     : %d (%s)", s.Slot, s.SlotLabel),
+		fmt.Sprintf("entry    : %s", s.EntryAt.Local().Format(time.RFC3339)),
+		fmt.Sprintf("exit     : %s", s.ExitAt.Local().Format(time.RFC3339)),
+		fmt.Sprintf("duration : %v", s.Duration().Round(time.Minute)),
+	}
+	if s.Discount.Sign() > 0 {
+		lines = append(lines,
+			fmt.Sprintf("subtotal : %v", s.Cost.Add(s.Discount)),
+			fmt.Sprintf("discount : %v (%s)", s.Discount, s.DiscountReason),
+		)
+	}
+	lines = append(lines, fmt.Sprintf("total    : %v", s.Cost))
+	if s.PaymentId != "" {
+		lines = append(lines, fmt.Sprintf("payment  : %s (%s)", s.PaymentId, s.Method))
+	}
+	if s.RequestId != "" {
+		lines = append(lines, fmt.Sprintf("ref      : %s", s.RequestId))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// HistoryQuery select the session by plate and the time range it overlap,
+// empty plate and zero time is unbounded
+type HistoryQuery struct {
+	PoliceNumber string    `json:"police_number,omitempty"`
+	From         time.Time `json:"from,omitempty"`
+	To           time.Time `json:"to,omitempty"`
+}
+
+// Match report whether the session is selected by the query
+func (q HistoryQuery) Match(s Session) bool {
+	if q.PoliceNumber != "" && !plate.Equal(q.PoliceNumber, s.PoliceNumber) {
+		return false
+	}
+
+	if !q.From.IsZero() && s.ExitAt.Before(q.From) {
+		return false
+	}
+
+	if !q.To.IsZero() && !s.EntryAt.Before(q.To) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,36 @@
+package types
+
+import "time"
+
+// Member is plate holding monthly pass, it park free until ValidUntil
+type Member struct {
+	PoliceNumber string    `json:"police_number"`
+	Name         string    `json:"name,omitempty"`
+	Since        time.Time `json:"since"`
+	ValidUntil   time.Time `json:"valid_until"`
+}
+
+// Active report whether the pass is still valid at t
+func (m Member) Active(t time.Time) bool {
+	return t.Before(m.ValidUntil)
+}
+
+// MemberDTO register or renew the monthly pass, zero Months is one month
+type MemberDTO struct {
+	RequestId    string `json:"request_id"`
+	PoliceNumber string `json:"police_number"`
+	Name         string `json:"name,omitempty"`
+	Months       int    `json:"months,omitempty"`
+}
+
+func (m MemberDTO) GetPoliceNumber() string {
+	return CarDTO{PoliceNumber: m.PoliceNumber}.GetPoliceNumber()
+}
+
+// GetMonths return the pass length, one month when not specified
+func (m MemberDTO) GetMonths() int {
+	if m.Months == 0 {
+		return 1
+	}
+	return m.Months
+}
@@ -0,0 +1,218 @@
+package types
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/plate"
+)
+
+// payment method, it is also the ledger account the money is collected in
+const (
+	PaymentCash    = "cash"
+	PaymentCard    = "card"
+	PaymentEWallet = "e-wallet"
+	PaymentPrepaid = "prepaid"
+)
+
+// DefaultCurrency is the currency every amount is priced in when the server is not configured with one
+const DefaultCurrency = "IDR"
+
+// AccountRevenue is the ledger account every parking fee is credited to
+const AccountRevenue = "revenue"
+
+// AccountOpening is the ledger account of revenue recorded before the ledger existed
+const AccountOpening = "opening"
+
+// ledger entry kind
+const (
+	EntryPayment = "payment"
+	EntryRefund  = "refund"
+	EntryVoid    = "void"
+	EntryOpening = "opening"
+)
+
+// payment status, folded from its ledger entries
+const (
+	PaymentPaid              = "paid"
+	PaymentPartiallyRefunded = "partially_refunded"
+	PaymentRefunded          = "refunded"
+	PaymentVoided            = "voided"
+)
+
+// PaymentMethods is every accepted payment method
+var PaymentMethods = []string{PaymentCash, PaymentCard, PaymentEWallet, PaymentPrepaid}
+
+// IsPaymentMethod report whether method is accepted
+func IsPaymentMethod(method string) bool {
+	for _, known := range PaymentMethods {
+		if method == known {
+			return true
+		}
+	}
+	return false
+}
+
+// LedgerEntry move Amount from the Credit account into the Debit account, payment debit
+// its method account and credit revenue, refund and void reverse it
+type LedgerEntry struct {
+	Id           string      `json:"id"`
+	PaymentId    string      `json:"payment_id,omitempty"`
+	Kind         string      `json:"kind"`
+	PoliceNumber string      `json:"police_number,omitempty"`
+	Debit        string      `json:"debit"`
+	Credit       string      `json:"credit"`
+	Amount       money.Money `json:"amount"`
+	At           time.Time   `json:"at"`
+	Reason       string      `json:"reason,omitempty"`
+
+	// RequestId is the client request the refund or void came with, never the entry id
+	RequestId string `json:"request_id,omitempty"`
+}
+
+// Payment is the charge of single completed session and what was given back of it
+type Payment struct {
+	Id           string      `json:"id"`
+	PoliceNumber string      `json:"police_number"`
+	Method       string      `json:"method"`
+	Amount       money.Money `json:"amount"`
+	Refunded     money.Money `json:"refunded"`
+	Status       string      `json:"status"`
+	PaidAt       time.Time   `json:"paid_at"`
+}
+
+// Remaining is the amount that can still be refunded
+func (p Payment) Remaining() money.Money {
+	if p.Status == PaymentVoided {
+		return money.FromMinor(0, p.Amount.Currency)
+	}
+	return p.Amount.Sub(p.Refunded)
+}
+
+// Ledger is every entry in the order it was recorded
+type Ledger []LedgerEntry
+
+// Balances return debit minus credit per account, the sum of every balance is zero
+func (l Ledger) Balances() map[string]money.Money {
+	balances := make(map[string]money.Money)
+	for _, entry := range l {
+		balances[entry.Debit] = balances[entry.Debit].Add(entry.Amount)
+		balances[entry.Credit] = balances[entry.Credit].Sub(entry.Amount)
+	}
+	return balances
+}
+
+// Revenue is the net parking fee earned, refund and void already taken off
+func (l Ledger) Revenue() money.Money {
+	return l.Balances()[AccountRevenue].Neg()
+}
+
+// Payments fold the entries into payment with its status, oldest first
+func (l Ledger) Payments() []Payment {
+	payments := []Payment{}
+	index := make(map[string]int)
+	for _, entry := range l {
+		switch entry.Kind {
+		case EntryPayment:
+			index[entry.Id] = len(payments)
+			payments = append(payments, Payment{
+				Id:           entry.Id,
+				PoliceNumber: entry.PoliceNumber,
+				Method:       entry.Debit,
+				Amount:       entry.Amount,
+				Refunded:     money.FromMinor(0, entry.Amount.Currency),
+				Status:       PaymentPaid,
+				PaidAt:       entry.At,
+			})
+		case EntryRefund:
+			if i, ok := index[entry.PaymentId]; ok {
+				payments[i].Refunded = payments[i].Refunded.Add(entry.Amount)
+				payments[i].Status = PaymentPartiallyRefunded
+				if payments[i].Refunded.Cmp(payments[i].Amount) >= 0 {
+					payments[i].Status = PaymentRefunded
+				}
+			}
+		case EntryVoid:
+			if i, ok := index[entry.PaymentId]; ok {
+				payments[i].Status = PaymentVoided
+			}
+		}
+	}
+	return payments
+}
+
+// Payment return the payment of the id
+func (l Ledger) Payment(id string) (payment Payment, ok bool) {
+	for _, candidate := range l.Payments() {
+		if candidate.Id == id {
+			return candidate, true
+		}
+	}
+	return
+}
+
+// PaymentQuery select the payment by plate and method, empty field match every payment
+type PaymentQuery struct {
+	PoliceNumber string `json:"police_number,omitempty"`
+	Method       string `json:"method,omitempty"`
+}
+
+// Match report whether the payment is selected by the query
+func (q PaymentQuery) Match(p Payment) bool {
+	if q.PoliceNumber != "" && !plate.Equal(q.PoliceNumber, p.PoliceNumber) {
+		return false
+	}
+	return q.Method == "" || strings.EqualFold(q.Method, p.Method)
+}
+
+// PaymentReport is the payment selected by the query and the ledger balance
+type PaymentReport struct {
+	Payments []Payment `json:"payments"`
+
+	// Collected is the net amount per payment method account
+	Collected map[string]money.Money `json:"collected"`
+	Revenue   money.Money            `json:"revenue"`
+}
+
+// ReportOf select the payment of the query and sum the ledger, amount is priced in currency
+func (l Ledger) ReportOf(query PaymentQuery, currency string) PaymentReport {
+	report := PaymentReport{
+		Payments:  []Payment{},
+		Collected: make(map[string]money.Money),
+		Revenue:   l.Revenue().In(currency),
+	}
+
+	for _, payment := range l.Payments() {
+		if query.Match(payment) {
+			report.Payments = append(report.Payments, payment)
+		}
+	}
+
+	for account, balance := range l.Balances() {
+		if IsPaymentMethod(account) {
+			report.Collected[account] = balance.In(currency)
+		}
+	}
+	return report
+}
+
+// SortedAccounts return the account of the balance in name order
+func SortedAccounts(balances map[string]money.Money) []string {
+	accounts := make([]string, 0, len(balances))
+	for account := range balances {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// RefundDTO give back Amount of the payment, zero Amount refund everything remaining,
+// plain Amount is priced in the payment currency
+type RefundDTO struct {
+	RequestId string      `json:"request_id"`
+	PaymentId string      `json:"payment_id"`
+	Amount    money.Money `json:"amount"`
+	Reason    string      `json:"reason,omitempty"`
+}
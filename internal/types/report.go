@@ -0,0 +1,88 @@
+package types
+
+import (
+	"time"
+
+	"github.com/khafidprayoga/parking-app/internal/money"
+)
+
+// report period, the row of a report cover one of it in server local time
+const (
+	PeriodHourly  = "hourly"
+	PeriodDaily   = "daily"
+	PeriodMonthly = "monthly"
+)
+
+// ReportPeriods is every accepted report period
+var ReportPeriods = []string{PeriodHourly, PeriodDaily, PeriodMonthly}
+
+// ReportQuery select the period and the time range of the report, zero From start at the
+// first recorded activity and zero To end now, empty Period is daily
+type ReportQuery struct {
+	Period string    `json:"period,omitempty"`
+	From   time.Time `json:"from,omitempty"`
+	To     time.Time `json:"to,omitempty"`
+}
+
+// GetPeriod return the report period, daily when not given
+func (q ReportQuery) GetPeriod() string {
+	if q.Period == "" {
+		return PeriodDaily
+	}
+	return q.Period
+}
+
+// PeriodStart return the start of the period containing at
+func PeriodStart(period string, at time.Time) time.Time {
+	at = at.In(time.Local)
+	switch period {
+	case PeriodHourly:
+		return time.Date(at.Year(), at.Month(), at.Day(), at.Hour(), 0, 0, 0, time.Local)
+	case PeriodMonthly:
+		return time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.Local)
+	}
+	return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// NextPeriod return the start of the period after the one starting at start
+func NextPeriod(period string, start time.Time) time.Time {
+	switch period {
+	case PeriodHourly:
+		return start.Add(time.Hour)
+	case PeriodMonthly:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// ReportRow is the activity of single period, or the whole range for the report total
+type ReportRow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// Revenue is the net ledger amount collected in the period, refund and void taken off
+	Revenue money.Money `json:"revenue"`
+
+	// Sessions is the completed session exited in the period and AverageMinutes their mean duration
+	Sessions       int     `json:"sessions"`
+	AverageMinutes float64 `json:"average_minutes"`
+
+	// PeakOccupancy is the most vehicle parked at the same time during the period
+	PeakOccupancy int `json:"peak_occupancy"`
+
+	// Turnover is the completed session per slot
+	Turnover float64 `json:"turnover"`
+}
+
+// AverageDuration is the mean duration of the completed session
+func (r ReportRow) AverageDuration() time.Duration {
+	return time.Duration(r.AverageMinutes * float64(time.Minute)).Round(time.Minute)
+}
+
+// Report is the revenue and occupancy of the lot per period
+type Report struct {
+	Period   string      `json:"period"`
+	Capacity int         `json:"capacity"`
+	Rows     []ReportRow `json:"rows"`
+	Total    ReportRow   `json:"total"`
+}
@@ -0,0 +1,53 @@
+package types
+
+import "time"
+
+// Reservation keep single slot for the plate, walk-in car can still take the slot until
+// From and it is held from then until the car arrive, it is cancelled, the car does not
+// show up before ExpireAt or its window end at To
+type Reservation struct {
+	Id           string    `json:"id"`
+	PoliceNumber string    `json:"police_number"`
+	Class        string    `json:"class"`
+	Slot         int       `json:"slot"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+
+	// ExpireAt is From plus the grace period, no-show release the slot after it
+	ExpireAt time.Time `json:"expire_at"`
+
+	// RequestId is the client request the reservation came with, never the reservation id
+	RequestId string `json:"request_id,omitempty"`
+}
+
+// Active report whether the reservation is not released yet at t
+func (r Reservation) Active(t time.Time) bool {
+	return t.Before(r.ExpireAt) && t.Before(r.To)
+}
+
+// Holds report whether the reservation keep its slot away from walk-in car at t
+func (r Reservation) Holds(t time.Time) bool {
+	return !t.Before(r.From) && r.Active(t)
+}
+
+// ReservationDTO is the reserve payload, zero Slot take the nearest available slot
+type ReservationDTO struct {
+	RequestId    string    `json:"request_id"`
+	PoliceNumber string    `json:"police_number"`
+	Class        string    `json:"class,omitempty"`
+	Slot         int       `json:"slot,omitempty"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+}
+
+func (r ReservationDTO) car() CarDTO {
+	return CarDTO{RequestId: r.RequestId, PoliceNumber: r.PoliceNumber, Class: r.Class}
+}
+
+func (r ReservationDTO) GetPoliceNumber() string {
+	return r.car().GetPoliceNumber()
+}
+
+func (r ReservationDTO) GetClass() string {
+	return r.car().GetClass()
+}
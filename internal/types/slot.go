@@ -0,0 +1,297 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// vehicle class, a slot is typed with the same class as the largest vehicle it can hold
+const (
+	ClassMoto = "moto"
+	ClassCar  = "car"
+	ClassVan  = "van"
+	ClassEV   = "ev"
+)
+
+// slotFit list the slot class each vehicle class can park on,
+// ev slot has charger so it is kept for ev only
+var slotFit = map[string][]string{
+	ClassMoto: {ClassMoto, ClassCar, ClassVan},
+	ClassCar:  {ClassCar, ClassVan},
+	ClassEV:   {ClassEV, ClassCar, ClassVan},
+	ClassVan:  {ClassVan},
+}
+
+// ValidClass report whether class is known vehicle or slot class
+func ValidClass(class string) bool {
+	_, ok := slotFit[class]
+	return ok
+}
+
+// SlotClassFor return every slot class the vehicle class fit on
+func SlotClassFor(vehicle string) []string {
+	return slotFit[vehicle]
+}
+
+// Fits report whether vehicle class can park on slot class
+func Fits(slot, vehicle string) bool {
+	for _, class := range slotFit[vehicle] {
+		if class == slot {
+			return true
+		}
+	}
+	return false
+}
+
+// Slot is single parking space, Label is the human readable location e.g. L2-B-14
+type Slot struct {
+	Number int    `json:"number"`
+	Label  string `json:"label"`
+	Level  int    `json:"level,omitempty"`
+	Zone   string `json:"zone,omitempty"`
+	Class  string `json:"class"`
+
+	// Draining slot is removed by resize but still occupied, it is out of capacity
+	// and dropped once the car leave
+	Draining bool `json:"draining,omitempty"`
+
+	// Maintenance is set while the slot is disabled
+	Maintenance *Maintenance `json:"maintenance,omitempty"`
+
+	// Reserved is set while the slot is reserved for arriving car, it only hold the
+	// slot within the reservation window
+	Reserved *Reservation `json:"reserved,omitempty"`
+}
+
+// Maintenance is why and since when the slot is taken out of allocation
+type Maintenance struct {
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+}
+
+// Available report whether the slot is in service, free one can take car unless it is Held
+func (s Slot) Available() bool {
+	return !s.Draining && s.Maintenance == nil
+}
+
+// Held report whether the slot is kept for its reservation at t
+func (s Slot) Held(t time.Time) bool {
+	return s.Reserved != nil && s.Reserved.Holds(t)
+}
+
+// SlotDetail is the slot with the car parked on it, Car is nil on free slot
+type SlotDetail struct {
+	Slot
+	Car *Car `json:"car,omitempty"`
+}
+
+// SlotGroup is run of consecutive slot with the same class, level and zone.
+// Level zero and empty zone is flat lot without hierarchy
+type SlotGroup struct {
+	Level int    `json:"level,omitempty"`
+	Zone  string `json:"zone,omitempty"`
+	Class string `json:"class"`
+	Count int    `json:"count"`
+}
+
+// location is the label prefix of the group, e.g. L2-B
+func (g SlotGroup) location() string {
+	parts := []string{}
+	if g.Level > 0 {
+		parts = append(parts, fmt.Sprintf("L%d", g.Level))
+	}
+	if g.Zone != "" {
+		parts = append(parts, g.Zone)
+	}
+	return strings.Join(parts, "-")
+}
+
+// LotLayout describe the slot of parking lot (site → level → zone → slot),
+// slot is numbered from the lowest level then the zone in the order it is declared
+type LotLayout []SlotGroup
+
+// UniformLayout is lot with capacity car slot, the layout before slot was typed
+func UniformLayout(capacity int) LotLayout {
+	return LotLayout{{Class: ClassCar, Count: capacity}}
+}
+
+// ParseLotLayout read layout from `6`, `car=20 moto=10 ev=4`
+// or with level and zone `L1-A:car=20 L1-B:moto=10 L2-A:car=20`
+func ParseLotLayout(s string) (layout LotLayout, err error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		err = fmt.Errorf("lot layout is empty")
+		return
+	}
+
+	if len(fields) == 1 && !strings.Contains(fields[0], "=") {
+		capacity, errCv := strconv.Atoi(fields[0])
+		if errCv != nil {
+			err = fmt.Errorf("failed to convert lot capacity %s to int", fields[0])
+			return
+		}
+		return UniformLayout(capacity), nil
+	}
+
+	for _, field := range fields {
+		group := SlotGroup{}
+		if location, rest, hasLocation := strings.Cut(field, ":"); hasLocation {
+			if group.Level, group.Zone, err = parseLocation(location); err != nil {
+				return
+			}
+			field = rest
+		}
+
+		class, strCount, ok := strings.Cut(field, "=")
+		if !ok {
+			err = fmt.Errorf("slot group %s must be class=count", field)
+			return
+		}
+
+		count, errCv := strconv.Atoi(strCount)
+		if errCv != nil {
+			err = fmt.Errorf("failed to convert slot count %s of %s to int", strCount, class)
+			return
+		}
+
+		group.Class = strings.ToLower(class)
+		group.Count = count
+		layout = append(layout, group)
+	}
+	return
+}
+
+// parseLocation read `L2-B`, `L2` or `B`
+func parseLocation(location string) (level int, zone string, err error) {
+	parts := strings.Split(location, "-")
+	if len(parts) > 2 {
+		err = fmt.Errorf("slot location %s must be L<level>-<zone>", location)
+		return
+	}
+
+	first := parts[0]
+	if len(first) > 1 && (first[0] == 'L' || first[0] == 'l') {
+		if lvl, errCv := strconv.Atoi(first[1:]); errCv == nil {
+			level = lvl
+			parts = parts[1:]
+		}
+	}
+
+	switch len(parts) {
+	case 0:
+	case 1:
+		zone = strings.ToUpper(parts[0])
+	default:
+		err = fmt.Errorf("slot location %s must be L<level>-<zone>", location)
+	}
+	return
+}
+
+func (l LotLayout) Capacity() (capacity int) {
+	for _, group := range l {
+		capacity += group.Count
+	}
+	return
+}
+
+// Slots expand the layout into numbered slot starting from 1, lowest level first
+// so the nearest slot is always the lowest number
+func (l LotLayout) Slots() []Slot {
+	groups := append(LotLayout(nil), l...)
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Level < groups[j].Level
+	})
+
+	slots := make([]Slot, 0, l.Capacity())
+	positions := make(map[string]int)
+	for _, group := range groups {
+		location := group.location()
+		for i := 0; i < group.Count; i++ {
+			positions[location]++
+
+			label := strconv.Itoa(positions[location])
+			if location != "" {
+				label = location + "-" + label
+			}
+
+			slots = append(slots, Slot{
+				Number: len(slots) + 1,
+				Label:  label,
+				Level:  group.Level,
+				Zone:   group.Zone,
+				Class:  group.Class,
+			})
+		}
+	}
+	return slots
+}
+
+func (l LotLayout) String() string {
+	groups := make([]string, len(l))
+	for i, group := range l {
+		groups[i] = fmt.Sprintf("%s=%d", group.Class, group.Count)
+		if location := group.location(); location != "" {
+			groups[i] = location + ":" + groups[i]
+		}
+	}
+	return strings.Join(groups, " ")
+}
+
+// ClassOccupancy count the taken slot of one slot class
+type ClassOccupancy struct {
+	Class    string `json:"class"`
+	Capacity int    `json:"capacity"`
+	Occupied int    `json:"occupied"`
+	Disabled int    `json:"disabled"`
+	Reserved int    `json:"reserved"`
+}
+
+// OccupancyOf count the capacity and taken slot per slot class, in the order the class first appear.
+// carList is aligned with slots
+func OccupancyOf(slots []Slot, carList []*Car) []ClassOccupancy {
+	occupancy := []ClassOccupancy{}
+	position := make(map[string]int)
+
+	for i, slot := range slots {
+		if slot.Draining {
+			continue
+		}
+
+		pos, ok := position[slot.Class]
+		if !ok {
+			pos = len(occupancy)
+			position[slot.Class] = pos
+			occupancy = append(occupancy, ClassOccupancy{Class: slot.Class})
+		}
+
+		occupancy[pos].Capacity++
+		if carList[i] != nil {
+			occupancy[pos].Occupied++
+		}
+		if slot.Maintenance != nil {
+			occupancy[pos].Disabled++
+		}
+		if slot.Reserved != nil {
+			occupancy[pos].Reserved++
+		}
+	}
+	return occupancy
+}
+
+// ResizeDTO is the resize_parking_lot payload, Layout is the same text as create_parking_lot
+type ResizeDTO struct {
+	Layout string `json:"layout"`
+	Drain  bool   `json:"drain,omitempty"`
+}
+
+// SlotDTO is the disable_slot and enable_slot payload
+type SlotDTO struct {
+	Number int    `json:"number"`
+	Reason string `json:"reason,omitempty"`
+
+	// Force disable occupied slot, the car stay until it leave
+	Force bool `json:"force,omitempty"`
+}
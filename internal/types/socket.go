@@ -1,7 +1,15 @@
 package types
 
+import "encoding/json"
+
+// DefaultLot is the lot of request without lot name
+const DefaultLot = "default"
+
 type Socket struct {
-	Command    string `json:"command"`
+	Command string `json:"command"`
+
+	// Lot is the name of the target parking lot, empty is DefaultLot
+	Lot        string `json:"lot,omitempty"`
 	Data       any    `json:"data"`
 	XRequestId string `json:"x_request_id"`
 }
@@ -12,6 +20,23 @@ const (
 )
 
 type SocketServerResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message"`
+	// XRequestId echo the request id, so pipelined response can be matched to its request
+	XRequestId string `json:"x_request_id"`
+	Status     string `json:"status"`
+
+	// Code is set on failed call, see ErrorCode constant
+	Code    ErrorCode `json:"code,omitempty"`
+	Message string    `json:"message"`
+
+	// Data is the structured result of the command, e.g. AppStatus for status
+	Data any `json:"data,omitempty"`
+}
+
+// Bind decode the response Data into dst, Data is generic json value after unmarshal
+func (r SocketServerResponse) Bind(dst any) error {
+	dataBytes, errMarshal := json.Marshal(r.Data)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	return json.Unmarshal(dataBytes, dst)
 }
@@ -0,0 +1,21 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// timeLayouts is the accepted command time, RFC3339 or server local time without zone
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"}
+
+// ParseTime read the command time, e.g. `2024-05-01T09:00`, `2024-05-01` or RFC3339
+func ParseTime(s string) (at time.Time, err error) {
+	for _, layout := range timeLayouts {
+		if parsed, errParse := time.ParseInLocation(layout, s, time.Local); errParse == nil {
+			return parsed, nil
+		}
+	}
+
+	err = fmt.Errorf("invalid time %s, use 2006-01-02T15:04, 2006-01-02 or RFC3339", s)
+	return
+}
@@ -0,0 +1,72 @@
+package wire
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MaxFrameSize is the biggest json payload allowed in single frame, both direction
+const MaxFrameSize = 4 << 20
+
+const headerSize = 4
+
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// WriteFrame marshal v as json and write it prefixed by 4 bytes big endian payload length
+func WriteFrame(w io.Writer, v any) (err error) {
+	payload, errMarshal := json.Marshal(v)
+	if errMarshal != nil {
+		err = fmt.Errorf("cannot marshal frame: %v", errMarshal)
+		return
+	}
+
+	if len(payload) > MaxFrameSize {
+		err = fmt.Errorf("%w: %d bytes exceed max frame size of %d bytes", ErrFrameTooLarge, len(payload), MaxFrameSize)
+		return
+	}
+
+	// single write so the header and payload is not interleaved with other writer
+	frame := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[headerSize:], payload)
+
+	if _, errWrite := w.Write(frame); errWrite != nil {
+		err = fmt.Errorf("cannot write frame: %w", errWrite)
+		return
+	}
+	return
+}
+
+// ReadFrame read one length prefixed frame and unmarshal its json payload into v,
+// io.EOF is returned as is when the peer close the connection between frames
+func ReadFrame(r io.Reader, v any) (err error) {
+	header := make([]byte, headerSize)
+	if _, errRead := io.ReadFull(r, header); errRead != nil {
+		if errors.Is(errRead, io.EOF) {
+			return io.EOF
+		}
+		err = fmt.Errorf("cannot read frame header: %w", errRead)
+		return
+	}
+
+	size := binary.BigEndian.Uint32(header)
+	if size > MaxFrameSize {
+		err = fmt.Errorf("%w: %d bytes exceed max frame size of %d bytes", ErrFrameTooLarge, size, MaxFrameSize)
+		return
+	}
+
+	payload := make([]byte, size)
+	if _, errRead := io.ReadFull(r, payload); errRead != nil {
+		err = fmt.Errorf("cannot read frame payload: %w", errRead)
+		return
+	}
+
+	if errUnmarshal := json.Unmarshal(payload, v); errUnmarshal != nil {
+		err = fmt.Errorf("cannot unmarshal frame: %v", errUnmarshal)
+		return
+	}
+	return
+}
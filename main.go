@@ -1,22 +1,26 @@
 package main
 
 import (
-	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
-	"github.com/google/uuid"
+	"github.com/khafidprayoga/parking-app/internal/client"
+	"github.com/khafidprayoga/parking-app/internal/export"
 	"github.com/khafidprayoga/parking-app/internal/extra"
+	"io"
 	"log"
-	"net"
 	"os"
-	"strconv"
 	"strings"
+	"time"
 
 	bootstrap "github.com/khafidprayoga/parking-app/internal/boot"
 
 	"github.com/khafidprayoga/parking-app/internal/types"
 )
 
+// importWindow is how many import instruction sent ahead before waiting their response
+const importWindow = 128
+
 // main to send input from file to running backend services
 func main() {
 
@@ -24,18 +28,59 @@ func main() {
 		"Parking App Service CLI:\n"+
 			"\nExample: `EXAMPLE`\n\n"+
 			"available commands:\n"+
-			"\t%s => start parking app server socket at :8080\n"+
-			"\t%s {lotCapacity:int} => for initialize parking lot size\n"+
-			"\t%s {carNumber:string} => parking a car\n"+
-			"\t%s {carNumber:string} {hours:int}  => for a car to exit parking area\n"+
-			"\t%s => view status of the parking area app service\n"+
+			"\t%s [--btree] [--data dir] [--http addr] [--tariff file] [--reservation-grace 15m] [--currency IDR] [--simulate-hours] => start parking app server socket at :8080\n"+
+			"\t%s {lotCapacity:int} | {[L<level>-<zone>:]class=count...} => for initialize parking lot size, e.g. L1-A:car=20 L1-B:moto=10\n"+
+			"\t%s {lotCapacity:int} | {[L<level>-<zone>:]class=count...} [--drain] => grow or shrink the parking lot, --drain let occupied removed slot empty first\n"+
+			"\t%s {slot:int} [reason:string] [--force] => take slot out of allocation for maintenance, --force for occupied slot\n"+
+			"\t%s {slot:int} => put the slot back into allocation\n"+
+			"\t%s {carNumber:string} {from:time} {to:time} [slot=int] [class=car|moto|van|ev] => hold slot until the car arrive, time is 2006-01-02T15:04 or RFC3339\n"+
+			"\t%s {carNumber:string} => release the reserved slot\n"+
+			"\t%s {carNumber:string} [months=int] [name=string] => register or renew monthly pass, member park free\n"+
+			"\t%s {carNumber:string} => remove the monthly pass\n"+
+			"\t%s => list the monthly pass member\n"+
+			"\t%s {carNumber:string} [class=car|moto|van|ev] [color=string] [make=string] [model=string] [photo=string] => parking a vehicle, photo is the entry or ANPR image reference\n"+
+			"\t%s {carNumber:string} [hours=int] [method=cash|card|e-wallet|prepaid] => for a car to exit parking area, billed by real elapsed time, hours only on a --simulate-hours server\n"+
+			"\t%s {paymentId:string} [amount:number] [reason:string] => refund the payment, everything remaining when amount omitted\n"+
+			"\t%s {paymentId:string} [reason:string] => cancel the whole payment\n"+
+			"\t%s [carNumber:string] [method=string] => list the payment and the revenue per payment method\n"+
+			"\t%s [level=int] [zone=string] => view status of the parking area app service\n"+
+			"\t%s {carNumber:string} => show the slot the car is parked at\n"+
+			"\t%s [color:string] [make=string] [class=string] => search parked car by its attribute\n"+
+			"\t%s {color:string} => list the plate of parked car with the color\n"+
+			"\t%s {color:string} => list the slot number of parked car with the color\n"+
+			"\t%s {carNumber:string} => show the slot number of the car\n"+
+			"\t%s {slot:int} => show the slot and the car parked on it\n"+
+			"\t%s [carNumber:string] [from=time] [to=time] => list completed parking session, time is 2006-01-02 or 2006-01-02T15:04\n"+
+			"\t%s [hourly|daily|monthly] [from=time] [to=time] [format=table|csv] => revenue, session, average duration, peak occupancy and turnover per period\n"+
+			"\t%s [state|sessions|all] [format=csv|jsonl|json] [columns=string,...] [from=time] [to=time] [out=file] => export the lot state and completed session, stdout when out omitted\n"+
 			"\t%s => to import a file with instruction list\n"+
-			"\thelp  => show this message",
+			"\thelp  => show this message\n"+
+			"\nevery command except serve take --lot {name} to target named lot, default lot when omitted",
 		types.CmdServe,
 		types.CmdCreateStore,
+		types.CmdResize,
+		types.CmdDisableSlot,
+		types.CmdEnableSlot,
+		types.CmdReserve,
+		types.CmdCancelReservation,
+		types.CmdAddMember,
+		types.CmdRemoveMember,
+		types.CmdMembers,
 		types.CmdPark,
 		types.CmdLeave,
+		types.CmdRefund,
+		types.CmdVoid,
+		types.CmdPayments,
 		types.CmdStatus,
+		types.CmdFind,
+		types.CmdSearch,
+		types.CmdPlatesByColor,
+		types.CmdSlotsByColor,
+		types.CmdSlotByPlate,
+		types.CmdSlot,
+		types.CmdHistory,
+		types.CmdReport,
+		types.CmdExport,
 		types.CmdImport,
 	)
 
@@ -48,8 +93,14 @@ func main() {
 	command := os.Args[1]
 	param := os.Args[2:]
 
+	// every client command may target named lot with --lot
+	lot := ""
+	if command != types.CmdServe {
+		lot, param = extra.ExtractLot(param)
+	}
+
 	// on check server state
-	if command != types.CmdStatus && command != types.CmdServe && len(os.Args) < 3 {
+	if command != types.CmdStatus && command != types.CmdHistory && command != types.CmdReport && command != types.CmdExport && command != types.CmdMembers && command != types.CmdPayments && command != types.CmdServe && len(param) == 0 {
 		defaultMsg = strings.Replace(defaultMsg, "EXAMPLE", fmt.Sprintf("parking-app %s 12", types.CmdCreateStore), -1)
 		log.Fatalln(defaultMsg)
 	}
@@ -59,11 +110,25 @@ func main() {
 		log.Println("Starting Parking App Server")
 		version := types.V1
 
-		if len(param) > 0 {
-			if param[0] == "--btree" {
-				version = types.V1BTree
-			}
+		serveFlag := flag.NewFlagSet(types.CmdServe, flag.ExitOnError)
+		useBTree := serveFlag.Bool("btree", false, "use btree backend implementation")
+		dataDir := serveFlag.String("data", bootstrap.AppConfig.DataDir, "directory to persist parking state, empty to keep it in memory only")
+		tariffFile := serveFlag.String("tariff", bootstrap.AppConfig.TariffFile, "json tariff rule set file (default 10 for first 2 hours, 10 per extra hour)")
+		httpAddr := serveFlag.String("http", bootstrap.AppConfig.HTTPAddr, "listen address of the http rest api, e.g. :8081 (disabled when empty)")
+		grace := serveFlag.Duration("reservation-grace", bootstrap.AppConfig.ReservationGrace, "how long reserved slot wait for the car after its start time")
+		currency := serveFlag.String("currency", bootstrap.AppConfig.Currency, "currency code of the payment ledger")
+		simulateHours := serveFlag.Bool("simulate-hours", bootstrap.AppConfig.SimulateHours, "bill the leaving car by the requested hours instead of the elapsed time, for simulation only")
+		_ = serveFlag.Parse(param)
+
+		if *useBTree {
+			version = types.V1BTree
 		}
+		bootstrap.AppConfig.DataDir = *dataDir
+		bootstrap.AppConfig.HTTPAddr = *httpAddr
+		bootstrap.AppConfig.TariffFile = *tariffFile
+		bootstrap.AppConfig.ReservationGrace = *grace
+		bootstrap.AppConfig.Currency = *currency
+		bootstrap.AppConfig.SimulateHours = *simulateHours
 
 		bootstrap.StartApp(version)
 	case types.CmdCreateStore:
@@ -74,10 +139,56 @@ func main() {
 			return
 		}
 
-		parkingLotCap := param[0]
+		// plain capacity or typed slot group, e.g. `car=20 moto=10 ev=4`
+		parkingLotCap := strings.Join(param, " ")
 		log.Printf("CLIENT:Creating parking with capacity of %v lot", parkingLotCap)
 
-		if errSendReq := sendRequest(command, parkingLotCap); errSendReq != nil {
+		if errSendReq := sendRequest(lot, command, parkingLotCap); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdResize:
+		resize := extra.ParseResizeArgs(param)
+		if resize.Layout == "" {
+			log.Printf("lot capacity not specified")
+			defaultMsg = strings.Replace(defaultMsg, "EXAMPLE", fmt.Sprintf("parking-app %s 12 --drain", types.CmdResize), -1)
+			log.Println(defaultMsg)
+			return
+		}
+
+		if errSendReq := sendRequest(lot, command, resize); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdDisableSlot, types.CmdEnableSlot:
+		slot, errParse := extra.ParseSlotArgs(param)
+		if errParse != nil {
+			log.Printf("%v", errParse)
+			defaultMsg = strings.Replace(defaultMsg, "EXAMPLE", fmt.Sprintf("parking-app %s 17 cleaning", types.CmdDisableSlot), -1)
+			log.Println(defaultMsg)
+			return
+		}
+
+		if errSendReq := sendRequest(lot, command, slot); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdReserve:
+		reservation, errParse := extra.ParseReserveArgs(param)
+		if errParse != nil {
+			log.Printf("%v", errParse)
+			defaultMsg = strings.Replace(defaultMsg, "EXAMPLE", fmt.Sprintf("parking-app %s KA-01-HH-270 2024-05-01T09:00 2024-05-01T12:00", types.CmdReserve), -1)
+			log.Println(defaultMsg)
+			return
+		}
+
+		if errSendReq := sendRequest(lot, command, reservation); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdCancelReservation:
+		policeNumber, errParse := extra.ParsePlate(param)
+		if errParse != nil {
+			log.Fatal(errParse)
+		}
+
+		if errSendReq := sendRequest(lot, command, types.ReservationDTO{PoliceNumber: policeNumber}); errSendReq != nil {
 			log.Fatal(errSendReq)
 		}
 	case types.CmdPark:
@@ -88,34 +199,162 @@ func main() {
 			return
 		}
 
-		if errSendReq := sendRequest(command, types.CarDTO{
-			PoliceNumber: param[0],
-		}); errSendReq != nil {
+		car, errParse := extra.ParseParkArgs(param)
+		if errParse != nil {
+			log.Fatal(errParse)
+		}
+
+		if errSendReq := sendRequest(lot, command, car); errSendReq != nil {
 			log.Fatal(errSendReq)
 		}
 	case types.CmdLeave:
-		if len(param) < 2 {
-			log.Printf("hours cost not specified")
-			defaultMsg = strings.Replace(defaultMsg, "EXAMPLE", fmt.Sprintf("parking-app %s KA-01-HH-270 2", types.CmdLeave), -1)
+		if len(param) < 1 {
+			log.Printf("car number not specified")
+			defaultMsg = strings.Replace(defaultMsg, "EXAMPLE", fmt.Sprintf("parking-app %s KA-01-HH-270", types.CmdLeave), -1)
 			log.Println(defaultMsg)
 			return
 		}
 
-		durationInHours, errParseDur := strconv.Atoi(param[1])
-		if errParseDur != nil {
-			log.Fatal(errParseDur)
+		// hours only override the real elapsed time
+		car, errParse := extra.ParseLeaveArgs(param)
+		if errParse != nil {
+			log.Fatal(errParse)
+		}
+
+		if errSendReq := sendRequest(lot, command, car); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdRefund, types.CmdVoid:
+		refund, errParse := extra.ParseRefundArgs(param, command == types.CmdRefund)
+		if errParse != nil {
+			log.Fatal(errParse)
+		}
+
+		if errSendReq := sendRequest(lot, command, refund); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdPayments:
+		query, errParse := extra.ParsePaymentArgs(param)
+		if errParse != nil {
+			log.Fatal(errParse)
 		}
 
-		if errSendReq := sendRequest(command, types.CarDTO{
-			PoliceNumber: param[0],
-			Hours:        durationInHours,
-		}); errSendReq != nil {
+		if errSendReq := sendRequest(lot, command, query); errSendReq != nil {
 			log.Fatal(errSendReq)
 		}
 	case types.CmdStatus:
-		if errSendReq := sendRequest(command, nil); errSendReq != nil {
+		var filter any
+		if len(param) > 0 {
+			statusFilter, errParse := extra.ParseStatusArgs(param)
+			if errParse != nil {
+				log.Fatal(errParse)
+			}
+			filter = statusFilter
+		}
+
+		if errSendReq := sendRequest(lot, command, filter); errSendReq != nil {
 			log.Fatal(errSendReq)
 		}
+	case types.CmdAddMember:
+		member, errParse := extra.ParseMemberArgs(param)
+		if errParse != nil {
+			log.Fatal(errParse)
+		}
+
+		if errSendReq := sendRequest(lot, command, member); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdRemoveMember:
+		policeNumber, errParse := extra.ParsePlate(param)
+		if errParse != nil {
+			log.Fatal(errParse)
+		}
+
+		if errSendReq := sendRequest(lot, command, types.MemberDTO{PoliceNumber: policeNumber}); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdMembers:
+		if errSendReq := sendRequest(lot, command, nil); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdPlatesByColor, types.CmdSlotsByColor:
+		if errSendReq := sendRequest(lot, command, strings.Join(param, " ")); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdFind, types.CmdSlotByPlate:
+		policeNumber, errParse := extra.ParsePlate(param)
+		if errParse != nil {
+			log.Fatal(errParse)
+		}
+
+		if errSendReq := sendRequest(lot, command, types.CarDTO{PoliceNumber: policeNumber}); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdSearch:
+		filter, errParse := extra.ParseSearchArgs(param)
+		if errParse != nil {
+			log.Fatal(errParse)
+		}
+
+		if errSendReq := sendRequest(lot, command, filter); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdSlot:
+		slot, errParse := extra.ParseSlotArgs(param)
+		if errParse != nil {
+			log.Fatal(errParse)
+		}
+
+		if errSendReq := sendRequest(lot, command, types.SlotDTO{Number: slot.Number}); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdHistory:
+		var query any
+		if len(param) > 0 {
+			historyQuery, errParse := extra.ParseHistoryArgs(param)
+			if errParse != nil {
+				log.Fatal(errParse)
+			}
+			query = historyQuery
+		}
+
+		if errSendReq := sendRequest(lot, command, query); errSendReq != nil {
+			log.Fatal(errSendReq)
+		}
+	case types.CmdReport:
+		query, format, errParse := extra.ParseReportArgs(param)
+		if errParse != nil {
+			log.Fatal(errParse)
+		}
+
+		if format == extra.FormatTable {
+			if errSendReq := sendRequest(lot, command, query); errSendReq != nil {
+				log.Fatal(errSendReq)
+			}
+			return
+		}
+
+		// csv is written alone so the output can be piped into a file
+		conn, errDial := dial()
+		if errDial != nil {
+			log.Fatal(errDial)
+		}
+		defer conn.Close()
+
+		report := types.Report{}
+		if errFetch := fetch(conn, lot, command, query, &report); errFetch != nil {
+			log.Fatal(errFetch)
+		}
+		printReportCSV(report)
+	case types.CmdExport:
+		opt, errParse := extra.ParseExportArgs(param)
+		if errParse != nil {
+			log.Fatal(errParse)
+		}
+
+		if errExport := exportLot(lot, opt); errExport != nil {
+			log.Fatal(errExport)
+		}
 	case types.CmdImport:
 		if len(param) < 1 {
 			log.Printf("command instruction file is not specified")
@@ -129,54 +368,128 @@ func main() {
 			log.Fatal(errParseCmd)
 		}
 
-		// sequential process
-		for _, cmd := range cmdList {
-			if errSend := sendRequest(cmd.Command, cmd.Data); errSend != nil {
-				log.Fatal(errSend)
-			}
+		conn, errDial := dial()
+		if errDial != nil {
+			log.Fatal(errDial)
+		}
+		defer conn.Close()
+
+		// pipelined over single connection, server still process them sequentially in file order
+		errPipeline := conn.Pipeline(cmdList, importWindow, func(req types.Socket, res types.SocketServerResponse) {
+			printResponse(req.Command, res)
+		})
+		if errPipeline != nil {
+			log.Fatal(errPipeline)
 		}
 	default:
 		log.Fatalln(defaultMsg)
 	}
 }
 
-func sendRequest(command string, data any) error {
-	conn, errDial := net.Dial("tcp", "localhost:8080")
+// dial open connection to the running backend services
+func dial() (*client.Client, error) {
+	conn, errDial := client.Dial("localhost:8080")
 	if errDial != nil {
 		e := errors.Unwrap(errDial)
-		if strings.Contains(e.Error(), "No connection could be made") {
-			return fmt.Errorf("application is down start the server first! with command `%s`", "parking-app serve")
+		if e != nil && (strings.Contains(e.Error(), "No connection could be made") || strings.Contains(e.Error(), "connection refused")) {
+			return nil, fmt.Errorf("application is down start the server first! with command `%s`", "parking-app serve")
 		}
-		return fmt.Errorf("cannot connect to server: %v", errDial)
+		return nil, fmt.Errorf("cannot connect to server: %v", errDial)
 	}
+	return conn, nil
+}
 
-	reqBytes, errMarshall := json.Marshal(types.Socket{
-		Command:    command,
-		Data:       data,
-		XRequestId: uuid.NewString(),
-	})
-	if errMarshall != nil {
-		return fmt.Errorf("cannot marshal json: %v", errMarshall)
+func sendRequest(lot, command string, data any) error {
+	conn, errDial := dial()
+	if errDial != nil {
+		return errDial
 	}
+	defer conn.Close()
 
-	_, errSend := conn.Write(reqBytes)
+	call, errSend := conn.Go(types.Socket{
+		Command: command,
+		Lot:     lot,
+		Data:    data,
+	})
 	if errSend != nil {
-		return fmt.Errorf("cannot send request: %v", errSend)
+		return errSend
+	}
+
+	res, errWait := conn.Wait(call)
+	if errWait != nil {
+		return errWait
+	}
+
+	printResponse(command, res)
+	return nil
+}
+
+// exportLot fetch the lot state and its completed session then write them as the option ask
+func exportLot(lot string, opt export.Options) (err error) {
+	conn, errDial := dial()
+	if errDial != nil {
+		return errDial
+	}
+	defer conn.Close()
+
+	data := export.Lot{Name: lot, ExportedAt: time.Now(), Sessions: []types.Session{}}
+	if data.Name == "" {
+		data.Name = types.DefaultLot
+	}
+
+	// status is always fetched, json document carry the lot summary
+	if err = fetch(conn, lot, types.CmdStatus, nil, &data.Status); err != nil {
+		return
+	}
+	if opt.GetDataset() != export.DatasetState {
+		if err = fetch(conn, lot, types.CmdHistory, types.HistoryQuery{From: opt.From, To: opt.To}, &data.Sessions); err != nil {
+			return
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if opt.Output != "" {
+		file, errCreate := os.Create(opt.Output)
+		if errCreate != nil {
+			return fmt.Errorf("cannot create export file: %v", errCreate)
+		}
+		defer func() {
+			if errClose := file.Close(); errClose != nil && err == nil {
+				err = fmt.Errorf("cannot write export file: %v", errClose)
+			}
+		}()
+		out = file
 	}
 
-	// reading response
-	buf := make([]byte, 2048)
-	size, errRead := conn.Read(buf)
-	if errRead != nil {
-		return fmt.Errorf("cannot read response: %v", errRead)
+	rows, errWrite := export.Write(out, data, opt)
+	if errWrite != nil {
+		return fmt.Errorf("cannot write export: %v", errWrite)
 	}
 
-	res := types.SocketServerResponse{}
-	if err := json.Unmarshal(buf[:size], &res); err != nil {
-		return fmt.Errorf("cannot unmarshal response: %v", errSend)
+	if opt.Output != "" {
+		log.Printf("exported %d %s row of lot %s into %s", rows, opt.GetDataset(), data.Name, opt.Output)
 	}
-	log.Printf("\nSERVER-STATUS: %s\n"+
-		"SERVER-RESPONSE: %s",
-		res.Status, res.Message)
 	return nil
 }
+
+// fetch send single command and decode its response data into dst
+func fetch(conn *client.Client, lot, command string, data any, dst any) error {
+	call, errSend := conn.Go(types.Socket{
+		Command: command,
+		Lot:     lot,
+		Data:    data,
+	})
+	if errSend != nil {
+		return errSend
+	}
+
+	res, errWait := conn.Wait(call)
+	if errWait != nil {
+		return errWait
+	}
+
+	if res.Status == types.SocketCallError {
+		return fmt.Errorf("%s failed with %s: %s", command, res.Code, res.Message)
+	}
+	return res.Bind(dst)
+}
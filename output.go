@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/types"
+)
+
+func printResponse(command string, res types.SocketServerResponse) {
+	if res.Status == types.SocketCallError {
+		log.Printf("\nSERVER-STATUS: %s (%s)\n"+
+			"SERVER-RESPONSE: %s",
+			res.Status, res.Code, res.Message)
+		return
+	}
+
+	log.Printf("\nSERVER-STATUS: %s\n"+
+		"SERVER-RESPONSE: %s",
+		res.Status, res.Message)
+
+	switch command {
+	case types.CmdStatus:
+		status := types.AppStatus{}
+		if errBind := res.Bind(&status); errBind != nil {
+			log.Printf("cannot decode status: %v", errBind)
+			return
+		}
+		printStatus(status)
+	case types.CmdLeave:
+		session := types.Session{}
+		if errBind := res.Bind(&session); errBind != nil {
+			log.Printf("cannot decode receipt: %v", errBind)
+			return
+		}
+		fmt.Println(session.Receipt())
+	case types.CmdSlot:
+		detail := types.SlotDetail{}
+		if errBind := res.Bind(&detail); errBind != nil {
+			log.Printf("cannot decode slot: %v", errBind)
+			return
+		}
+		if note := slotNote(detail.Slot); note != "" {
+			fmt.Println("note:", note)
+		}
+	case types.CmdPayments:
+		report := types.PaymentReport{}
+		if errBind := res.Bind(&report); errBind != nil {
+			log.Printf("cannot decode payment: %v", errBind)
+			return
+		}
+		printPayments(report)
+	case types.CmdMembers:
+		members := []types.Member{}
+		if errBind := res.Bind(&members); errBind != nil {
+			log.Printf("cannot decode member: %v", errBind)
+			return
+		}
+		printMembers(members)
+	case types.CmdSearch:
+		cars := []types.Car{}
+		if errBind := res.Bind(&cars); errBind != nil {
+			log.Printf("cannot decode search result: %v", errBind)
+			return
+		}
+		printCars(cars)
+	case types.CmdHistory:
+		sessions := []types.Session{}
+		if errBind := res.Bind(&sessions); errBind != nil {
+			log.Printf("cannot decode history: %v", errBind)
+			return
+		}
+		printHistory(sessions)
+	case types.CmdReport:
+		report := types.Report{}
+		if errBind := res.Bind(&report); errBind != nil {
+			log.Printf("cannot decode report: %v", errBind)
+			return
+		}
+		printReport(report)
+	}
+}
+
+// printHistory render the completed session as table
+func printHistory(sessions []types.Session) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLATE\tCLASS\tSLOT\tENTRY\tEXIT\tDURATION\tCOST")
+
+	total := money.Money{}
+	for _, session := range sessions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%v\t%v\n",
+			session.PoliceNumber,
+			session.Class,
+			session.SlotLabel,
+			session.EntryAt.Local().Format(time.RFC3339),
+			session.ExitAt.Local().Format(time.RFC3339),
+			session.Duration().Round(time.Minute),
+			session.Cost,
+		)
+		total = total.Add(session.Cost)
+	}
+	_ = w.Flush()
+
+	fmt.Printf("session: %d, total: %v\n", len(sessions), total)
+}
+
+// reportLabels is the period label layout of the report row
+var reportLabels = map[string]string{
+	types.PeriodHourly:  "2006-01-02 15:00",
+	types.PeriodDaily:   "2006-01-02",
+	types.PeriodMonthly: "2006-01",
+}
+
+// printReport render the report as table, one row per period then the total
+func printReport(report types.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PERIOD\tREVENUE\tSESSIONS\tAVG DURATION\tPEAK\tTURNOVER")
+
+	printRow := func(label string, row types.ReportRow) {
+		fmt.Fprintf(w, "%s\t%v\t%d\t%v\t%d/%d\t%.2f\n",
+			label,
+			row.Revenue,
+			row.Sessions,
+			row.AverageDuration(),
+			row.PeakOccupancy,
+			report.Capacity,
+			row.Turnover,
+		)
+	}
+
+	for _, row := range report.Rows {
+		printRow(types.PeriodStart(report.Period, row.Start).Format(reportLabels[report.Period]), row)
+	}
+	printRow("total", report.Total)
+	_ = w.Flush()
+}
+
+// printReportCSV write the report row as csv into stdout, the server response stay on stderr
+func printReportCSV(report types.Report) {
+	w := csv.NewWriter(os.Stdout)
+	_ = w.Write([]string{"start", "end", "revenue", "currency", "sessions", "average_minutes", "peak_occupancy", "turnover"})
+	for _, row := range report.Rows {
+		_ = w.Write([]string{
+			row.Start.Local().Format(time.RFC3339),
+			row.End.Local().Format(time.RFC3339),
+			row.Revenue.Amount(),
+			row.Revenue.Currency,
+			strconv.Itoa(row.Sessions),
+			strconv.FormatFloat(row.AverageMinutes, 'f', -1, 64),
+			strconv.Itoa(row.PeakOccupancy),
+			strconv.FormatFloat(row.Turnover, 'f', -1, 64),
+		})
+	}
+	w.Flush()
+	if errFlush := w.Error(); errFlush != nil {
+		log.Printf("cannot write report csv: %v", errFlush)
+	}
+}
+
+// printPayments render the payment as table and the collected amount per method
+func printPayments(report types.PaymentReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PAYMENT\tPLATE\tMETHOD\tAMOUNT\tREFUNDED\tSTATUS\tPAID AT")
+	for _, payment := range report.Payments {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%v\t%v\t%s\t%s\n",
+			payment.Id,
+			payment.PoliceNumber,
+			payment.Method,
+			payment.Amount,
+			payment.Refunded,
+			payment.Status,
+			payment.PaidAt.Local().Format(time.RFC3339),
+		)
+	}
+	_ = w.Flush()
+
+	collected := []string{}
+	for _, method := range types.SortedAccounts(report.Collected) {
+		collected = append(collected, fmt.Sprintf("%s %v", method, report.Collected[method]))
+	}
+
+	fmt.Printf("revenue: %v\n", report.Revenue)
+	if len(collected) > 0 {
+		fmt.Printf("collected: %s\n", strings.Join(collected, ", "))
+	}
+}
+
+// printMembers render the monthly pass member as table
+func printMembers(members []types.Member) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLATE\tNAME\tSINCE\tVALID UNTIL")
+	for _, member := range members {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			member.PoliceNumber,
+			orDash(member.Name),
+			member.Since.Local().Format(time.RFC3339),
+			member.ValidUntil.Local().Format(time.RFC3339),
+		)
+	}
+	_ = w.Flush()
+}
+
+// printCars render the parked car with its attribute as table
+func printCars(cars []types.Car) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SLOT\tPLATE\tCLASS\tCOLOR\tMAKE\tMODEL\tPARKED SINCE")
+	for _, car := range cars {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			car.SlotLabel,
+			car.PoliceNumber,
+			car.GetClass(),
+			orDash(car.Color),
+			orDash(car.Make),
+			orDash(car.Model),
+			car.ParkingAt.Local().Format(time.RFC3339),
+		)
+	}
+	_ = w.Flush()
+}
+
+// printStatus render the parking lot as slot table
+func printStatus(status types.AppStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SLOT\tLABEL\tCLASS\tPLATE\tCOLOR\tPARKED SINCE\tNOTE")
+
+	for i, car := range status.CarList {
+		slot := types.Slot{Number: i + 1, Label: "-", Class: "-"}
+		if i < len(status.Slots) {
+			slot = status.Slots[i]
+		}
+
+		plate, color, since := "-", "-", "-"
+		if car != nil {
+			plate, color, since = car.PoliceNumber, orDash(car.Color), car.ParkingAt.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", slot.Number, slot.Label, slot.Class, plate, color, since, slotNote(slot))
+	}
+	_ = w.Flush()
+
+	occupancy := make([]string, len(status.Occupancy))
+	for i, class := range status.Occupancy {
+		occupancy[i] = fmt.Sprintf("%s %d/%d", class.Class, class.Occupied, class.Capacity)
+		if class.Disabled > 0 {
+			occupancy[i] += fmt.Sprintf(" (%d disabled)", class.Disabled)
+		}
+		if class.Reserved > 0 {
+			occupancy[i] += fmt.Sprintf(" (%d reserved)", class.Reserved)
+		}
+	}
+
+	fmt.Printf("capacity: %d, revenue: %v, transaction: %d\n",
+		status.LotParkingCapacity, status.Revenue, status.TxCount)
+	if len(occupancy) > 0 {
+		fmt.Printf("occupancy: %s\n", strings.Join(occupancy, ", "))
+	}
+}
+
+// slotNote describe why the slot is out of allocation
+func slotNote(slot types.Slot) string {
+	switch {
+	case slot.Draining:
+		return "draining"
+	case slot.Maintenance != nil:
+		note := "disabled since " + slot.Maintenance.Since.Local().Format(time.RFC3339)
+		if slot.Maintenance.Reason != "" {
+			note += ": " + slot.Maintenance.Reason
+		}
+		return note
+	case slot.Reserved != nil:
+		release := slot.Reserved.ExpireAt
+		if slot.Reserved.To.Before(release) {
+			release = slot.Reserved.To
+		}
+		return fmt.Sprintf("reserved for %s from %s until %s", slot.Reserved.PoliceNumber, slot.Reserved.From.Local().Format(time.RFC3339), release.Local().Format(time.RFC3339))
+	}
+	return ""
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -11,7 +11,7 @@ import (
 
 // setupParkingService adalah helper function untuk setup service
 func setupParkingService(useCase contract.IParkingUseCase, capacity int) {
-	useCase.OpenParkingArea(capacity)
+	useCase.OpenParkingArea(types.UniformLayout(capacity))
 }
 
 func BenchmarkParkingUseCase_EnterArea(b *testing.B) {
@@ -120,5 +120,5 @@ func BenchmarkParkingUseCase_Parallel(b *testing.B) {
 
 // getParkingUseCase adalah helper function untuk mendapatkan implementasi IParkingUseCase
 func getParkingUseCase() contract.IParkingUseCase {
-	return backend.NewParkingServiceBTree()
+	return backend.NewParkingServiceBTree(backend.WithSimulatedHours())
 }
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/internal/client"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/khafidprayoga/parking-app/internal/wire"
+	"github.com/stretchr/testify/assert"
+)
+
+// startEchoServer answer every request on a connection in order, echoing the command as message
+func startEchoServer(t *testing.T) (addr string, accepted *int) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	count := 0
+	go func() {
+		for {
+			conn, errAcc := listener.Accept()
+			if errAcc != nil {
+				return
+			}
+			count++
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+				for {
+					req := types.Socket{}
+					if errRead := wire.ReadFrame(conn, &req); errRead != nil {
+						return
+					}
+
+					_ = wire.WriteFrame(conn, types.SocketServerResponse{
+						XRequestId: req.XRequestId,
+						Status:     types.SocketCallSuccess,
+						Message:    fmt.Sprintf("%s %v", req.Command, req.Data),
+					})
+				}
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().String(), &count
+}
+
+func TestClient_PipelineOnSingleConnection(t *testing.T) {
+	addr, accepted := startEchoServer(t)
+
+	conn, err := client.Dial(addr)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	reqList := make([]types.Socket, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		reqList = append(reqList, types.Socket{
+			Command:    types.CmdPark,
+			Data:       fmt.Sprintf("B%d", i),
+			XRequestId: fmt.Sprintf("req-%d", i),
+		})
+	}
+
+	received := 0
+	err = conn.Pipeline(reqList, 64, func(req types.Socket, res types.SocketServerResponse) {
+		assert.Equal(t, req.XRequestId, res.XRequestId)
+		assert.Equal(t, fmt.Sprintf("park B%d", received), res.Message)
+		received++
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, received)
+
+	// the same connection keep serving after the pipeline
+	res, err := conn.Do(types.CmdStatus, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, types.SocketCallSuccess, res.Status)
+	assert.NotEmpty(t, res.XRequestId)
+	assert.Equal(t, 1, *accepted)
+}
+
+func TestClient_ServerGone(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	go func() {
+		conn, errAcc := listener.Accept()
+		if errAcc != nil {
+			return
+		}
+		// reject the request without answering
+		req := types.Socket{}
+		_ = wire.ReadFrame(conn, &req)
+		conn.Close()
+	}()
+
+	conn, err := client.Dial(listener.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Do(types.CmdStatus, nil)
+	assert.Error(t, err)
+	listener.Close()
+}
@@ -0,0 +1,129 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/extra"
+	"github.com/khafidprayoga/parking-app/internal/server"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColourQuery_CarsByColor(t *testing.T) {
+	for name, newBackend := range allBackends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+			_, err := uc.CarsByColor("White")
+			assert.ErrorIs(t, err, contract.ErrNotInitialized)
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(6)))
+
+			for _, car := range []types.CarDTO{
+				{PoliceNumber: "KA-01-HH-1234", Color: "White"},
+				{PoliceNumber: "KA-01-HH-9999", Color: "white"},
+				{PoliceNumber: "KA-01-BB-0001", Color: "Black"},
+				{PoliceNumber: "KA-01-HH-7777", Color: "Red"},
+				{PoliceNumber: "KA-01-HH-2701"},
+			} {
+				_, err = uc.EnterArea(car)
+				assert.NoError(t, err)
+			}
+
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "KA-01-HH-1234", Hours: 1})
+			assert.NoError(t, err)
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "KA-01-HH-7777", Hours: 1})
+			assert.NoError(t, err)
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "KA-01-P-333", Color: "WHITE"})
+			assert.NoError(t, err)
+
+			cars, err := uc.CarsByColor(" White ")
+			assert.NoError(t, err)
+			assert.Len(t, cars, 2)
+			assert.Equal(t, "KA-01-P-333", cars[0].PoliceNumber)
+			assert.Equal(t, 1, cars[0].AreaNumber)
+			assert.Equal(t, "KA-01-HH-9999", cars[1].PoliceNumber)
+
+			cars, err = uc.CarsByColor("red")
+			assert.NoError(t, err)
+			assert.Empty(t, cars)
+
+			_, err = uc.CarsByColor(" ")
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+		})
+	}
+}
+
+func TestColourQuery_RestoreColorIndex(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			before := newBackend(db)
+			assert.NoError(t, before.Restore())
+			assert.NoError(t, before.OpenParkingArea(types.UniformLayout(3)))
+			_, err = before.EnterArea(types.CarDTO{PoliceNumber: "B1", Color: "white"})
+			assert.NoError(t, err)
+			assert.NoError(t, before.Compact())
+			_, err = before.EnterArea(types.CarDTO{PoliceNumber: "B2", Color: "white"})
+			assert.NoError(t, err)
+
+			after := newBackend(db)
+			assert.NoError(t, after.Restore())
+
+			cars, err := after.CarsByColor("White")
+			assert.NoError(t, err)
+			assert.Len(t, cars, 2)
+
+			cars, err = after.SearchCars(types.CarFilter{Color: "white", Class: types.ClassCar})
+			assert.NoError(t, err)
+			assert.Len(t, cars, 2)
+		})
+	}
+}
+
+func TestColourQuery_Commands(t *testing.T) {
+	commands := "create_parking_lot 4\n" +
+		"park KA-01-HH-1234 color=White\n" +
+		"park KA-01-HH-9999 color=White\n" +
+		"park KA-01-BB-0001 color=Black\n" +
+		"registration_numbers_for_cars_with_colour White\n" +
+		"slot_numbers_for_cars_with_colour White\n" +
+		"slot_number_for_registration_number KA-01-HH-9999\n" +
+		"slot_numbers_for_cars_with_colour Red\n"
+
+	cmdList, err := extra.ParseImportCmd(writeCommand(t, commands))
+	assert.NoError(t, err)
+	assert.Len(t, cmdList, 8)
+
+	srv := server.CreateAppServer(backend.NewParkingServiceBTree(backend.WithSimulatedHours()))
+	responses := []string{}
+	for _, cmd := range cmdList {
+		response, _, errHandle := srv.HandleIncomingMsg(cmd)
+		assert.NoError(t, errHandle)
+		responses = append(responses, response)
+	}
+
+	assert.Equal(t, []string{"KA-01-HH-1234, KA-01-HH-9999", "1, 2", "2", "Not found"}, responses[4:])
+
+	_, data, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdSlotsByColor, Data: "white"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, data)
+
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdSlotByPlate, Data: types.CarDTO{PoliceNumber: "MH-04-AY-1111"}})
+	assert.ErrorIs(t, err, contract.ErrCarNotFound)
+
+	_, err = extra.ParseImportCmd(writeCommand(t, "slot_numbers_for_cars_with_colour\n"))
+	assert.Error(t, err)
+}
+
+func writeCommand(t *testing.T, commands string) string {
+	path := filepath.Join(t.TempDir(), "command")
+	assert.NoError(t, os.WriteFile(path, []byte(commands), 0o644))
+	return path
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/server"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var allBackends = map[string]func() contract.IParkingUseCase{
+	"slice": func() contract.IParkingUseCase { return backend.NewParkingService(backend.WithSimulatedHours()) },
+	"btree": func() contract.IParkingUseCase { return backend.NewParkingServiceBTree(backend.WithSimulatedHours()) },
+}
+
+func TestErrors_SameSentinelOnEveryBackend(t *testing.T) {
+	for name, newBackend := range allBackends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+
+			_, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B1234ABC"})
+			assert.ErrorIs(t, err, contract.ErrNotInitialized)
+
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B1234ABC", Hours: 1})
+			assert.ErrorIs(t, err, contract.ErrNotInitialized)
+
+			assert.ErrorIs(t, uc.OpenParkingArea(types.UniformLayout(0)), contract.ErrInvalidRequest)
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(1)))
+			assert.ErrorIs(t, uc.OpenParkingArea(types.UniformLayout(1)), contract.ErrAlreadyInitialized)
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: ""})
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B1234ABC"})
+			assert.NoError(t, err)
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B1234ABC"})
+			assert.ErrorIs(t, err, contract.ErrAlreadyParked)
+			assert.Equal(t, types.ErrCodeAlreadyParked, contract.CodeOf(err))
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B5678DEF"})
+			assert.ErrorIs(t, err, contract.ErrLotFull)
+
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B1234ABC", Hours: -1})
+			assert.ErrorIs(t, err, contract.ErrInvalidDuration)
+
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B5678DEF", Hours: 1})
+			assert.ErrorIs(t, err, contract.ErrCarNotFound)
+			assert.Equal(t, types.ErrCodeNotFound, contract.CodeOf(err))
+		})
+	}
+}
+
+func TestErrors_CodeSurviveServerWrapping(t *testing.T) {
+	srv := server.CreateAppServer(backend.NewParkingServiceBTree(backend.WithSimulatedHours()))
+
+	_, _, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Data: "1"})
+	assert.NoError(t, err)
+
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Data: "1"})
+	assert.Equal(t, types.ErrCodeAlreadyInitialized, contract.CodeOf(err))
+
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdLeave, Data: map[string]any{"police_number": "B1", "hours": 1}})
+	assert.Equal(t, types.ErrCodeNotFound, contract.CodeOf(err))
+
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdPark, Data: "B1"})
+	assert.Equal(t, types.ErrCodeInvalidRequest, contract.CodeOf(err))
+
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: "fly"})
+	assert.Equal(t, types.ErrCodeInvalidRequest, contract.CodeOf(err))
+}
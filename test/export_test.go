@@ -0,0 +1,133 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/clock"
+	"github.com/khafidprayoga/parking-app/internal/export"
+	"github.com/khafidprayoga/parking-app/internal/extra"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport_FormatColumnAndRange(t *testing.T) {
+	start := time.Date(2024, 6, 3, 8, 0, 0, 0, time.Local)
+	now := clock.NewFake(start)
+	uc := backend.NewParkingService(backend.WithClock(now))
+	assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(2)))
+
+	_, err := uc.EnterArea(types.CarDTO{RequestId: "req-1", PoliceNumber: "B1", Color: "white"})
+	assert.NoError(t, err)
+	_, err = uc.EnterArea(types.CarDTO{RequestId: "req-2", PoliceNumber: "B2"})
+	assert.NoError(t, err)
+	now.Advance(3 * time.Hour)
+	_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B2", Method: types.PaymentCard})
+	assert.NoError(t, err)
+
+	status, err := uc.Status()
+	assert.NoError(t, err)
+	sessions, err := uc.History(types.HistoryQuery{})
+	assert.NoError(t, err)
+	lot := export.Lot{Name: types.DefaultLot, Status: status, Sessions: sessions, ExportedAt: now.Now()}
+
+	write := func(opt export.Options) (string, int) {
+		buf := bytes.Buffer{}
+		rows, err := export.Write(&buf, lot, opt)
+		assert.NoError(t, err)
+		return buf.String(), rows
+	}
+
+	// csv of the chosen session column in the chosen order
+	out, rows := write(export.Options{Columns: []string{"plate", "cost", "currency", "method", "duration_minutes"}})
+	assert.Equal(t, 1, rows)
+	assert.Equal(t, "plate,cost,currency,method,duration_minutes\nB2,20,IDR,card,180\n", out)
+
+	// the session exited before the range is left out, header stay
+	out, rows = write(export.Options{Columns: []string{"plate"}, From: start.Add(4 * time.Hour)})
+	assert.Equal(t, 0, rows)
+	assert.Equal(t, "plate\n", out)
+
+	// one json object per slot, free slot has empty car column
+	out, rows = write(export.Options{Dataset: export.DatasetState, Format: export.FormatJSONL, Columns: []string{"slot", "status", "plate", "color", "parked_at"}})
+	assert.Equal(t, 2, rows)
+	assert.Equal(t,
+		`{"slot":1,"status":"occupied","plate":"B1","color":"White","parked_at":"`+start.Format(time.RFC3339)+`"}`+"\n"+
+			`{"slot":2,"status":"free","plate":"","color":"","parked_at":""}`+"\n",
+		out)
+
+	// every dataset tagged in json lines
+	out, rows = write(export.Options{Dataset: export.DatasetAll, Format: export.FormatJSONL})
+	assert.Equal(t, 3, rows)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], `{"dataset":"state","slot":1,`))
+	assert.True(t, strings.HasPrefix(lines[2], `{"dataset":"sessions","request_id":"req-2",`))
+
+	// single document with the lot summary
+	out, _ = write(export.Options{Dataset: export.DatasetAll, Format: export.FormatJSON})
+	document := struct {
+		Lot      string           `json:"lot"`
+		Capacity int              `json:"capacity"`
+		Revenue  string           `json:"revenue"`
+		Currency string           `json:"currency"`
+		State    []map[string]any `json:"state"`
+		Sessions []map[string]any `json:"sessions"`
+	}{}
+	assert.NoError(t, json.Unmarshal([]byte(out), &document))
+	assert.Equal(t, types.DefaultLot, document.Lot)
+	assert.Equal(t, 2, document.Capacity)
+	assert.Equal(t, "20", document.Revenue)
+	assert.Equal(t, types.DefaultCurrency, document.Currency)
+	assert.Len(t, document.State, 2)
+	assert.Len(t, document.Sessions, 1)
+	assert.Equal(t, "B2", document.Sessions[0]["plate"])
+
+	// ranged state keep only the car parked inside the range
+	out, rows = write(export.Options{Dataset: export.DatasetState, Columns: []string{"plate"}, From: start, To: start.Add(time.Minute)})
+	assert.Equal(t, 1, rows)
+	assert.Equal(t, "plate\nB1\n", out)
+}
+
+func TestExport_InvalidOptions(t *testing.T) {
+	for name, opt := range map[string]export.Options{
+		"unknown dataset":     {Dataset: "members"},
+		"unknown format":      {Format: "xlsx"},
+		"csv of all dataset":  {Dataset: export.DatasetAll},
+		"column of all":       {Dataset: export.DatasetAll, Format: export.FormatJSON, Columns: []string{"plate"}},
+		"unknown column":      {Columns: []string{"plate", "colour"}},
+		"state column":        {Columns: []string{"status"}},
+		"range end too early": {From: time.Now(), To: time.Now().Add(-time.Hour)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := export.Write(&bytes.Buffer{}, export.Lot{}, opt)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestExport_ParseArgs(t *testing.T) {
+	opt, err := extra.ParseExportArgs([]string{"State", "format=JSONL", "columns=slot, plate,,status", "from=2024-06-03", "out=state.jsonl"})
+	assert.NoError(t, err)
+	assert.Equal(t, export.Options{
+		Dataset: export.DatasetState,
+		Format:  export.FormatJSONL,
+		Columns: []string{"slot", "plate", "status"},
+		From:    time.Date(2024, 6, 3, 0, 0, 0, 0, time.Local),
+		Output:  "state.jsonl",
+	}, opt)
+
+	opt, err = extra.ParseExportArgs(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, export.DatasetSessions, opt.GetDataset())
+	assert.Equal(t, export.FormatCSV, opt.GetFormat())
+
+	for _, invalid := range [][]string{{"all"}, {"columns=colour"}, {"sheet=1"}, {"to=tomorrow"}} {
+		_, err = extra.ParseExportArgs(invalid)
+		assert.Error(t, err)
+	}
+}
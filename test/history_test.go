@@ -0,0 +1,151 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/clock"
+	"github.com/khafidprayoga/parking-app/internal/extra"
+	"github.com/khafidprayoga/parking-app/internal/server"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory_QueryByPlateAndRange(t *testing.T) {
+	start := time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)
+	now := clock.NewFake(start)
+	backends := map[string]func() contract.IParkingUseCase{
+		"slice": func() contract.IParkingUseCase {
+			return backend.NewParkingService(backend.WithSimulatedHours(), backend.WithClock(now))
+		},
+		"btree": func() contract.IParkingUseCase {
+			return backend.NewParkingServiceBTree(backend.WithSimulatedHours(), backend.WithClock(now))
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			now.Set(start)
+			uc := newBackend()
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(2)))
+
+			// B1 park twice, one day apart
+			for _, plate := range []string{"B1", "B2", "B1"} {
+				_, err := uc.EnterArea(types.CarDTO{RequestId: "req-" + plate, PoliceNumber: plate})
+				assert.NoError(t, err)
+				now.Advance(3 * time.Hour)
+				_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: plate})
+				assert.NoError(t, err)
+				now.Advance(21 * time.Hour)
+			}
+
+			sessions, err := uc.History(types.HistoryQuery{})
+			assert.NoError(t, err)
+			assert.Len(t, sessions, 3)
+			assert.Equal(t, types.Session{
+				RequestId:    "req-B1",
+				PoliceNumber: "B1",
+				Class:        types.ClassCar,
+				Slot:         1,
+				SlotLabel:    "1",
+				EntryAt:      start,
+				ExitAt:       start.Add(3 * time.Hour),
+				Cost:         idr(20),
+				Discount:     idr(0),
+				PaymentId:    sessions[0].PaymentId,
+				Method:       types.PaymentCash,
+			}, sessions[0])
+			assert.NotEmpty(t, sessions[0].PaymentId)
+
+			sessions, err = uc.History(types.HistoryQuery{PoliceNumber: "b1"})
+			assert.NoError(t, err)
+			assert.Len(t, sessions, 2)
+
+			// second day only
+			sessions, err = uc.History(types.HistoryQuery{From: start.Add(24 * time.Hour), To: start.Add(48 * time.Hour)})
+			assert.NoError(t, err)
+			assert.Len(t, sessions, 1)
+			assert.Equal(t, "B2", sessions[0].PoliceNumber)
+
+			// session overlapping the range bound is included
+			sessions, err = uc.History(types.HistoryQuery{PoliceNumber: "B1", From: start.Add(2 * time.Hour), To: start.Add(49 * time.Hour)})
+			assert.NoError(t, err)
+			assert.Len(t, sessions, 2)
+		})
+	}
+}
+
+func TestHistory_RestoreSession(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			before := newBackend(db)
+			assert.NoError(t, before.Restore())
+			assert.NoError(t, before.OpenParkingArea(types.UniformLayout(2)))
+			for _, plate := range []string{"B1", "B2"} {
+				_, err = before.EnterArea(types.CarDTO{PoliceNumber: plate})
+				assert.NoError(t, err)
+				_, err = before.LeaveArea(types.CarDTO{PoliceNumber: plate, Hours: 1})
+				assert.NoError(t, err)
+
+				// first session folded into snapshot, second one only in the log
+				if plate == "B1" {
+					assert.NoError(t, before.Compact())
+				}
+			}
+
+			after := newBackend(db)
+			assert.NoError(t, after.Restore())
+
+			sessions, err := after.History(types.HistoryQuery{})
+			assert.NoError(t, err)
+			assert.Len(t, sessions, 2)
+			assert.Equal(t, "B1", sessions[0].PoliceNumber)
+			assert.Equal(t, "B2", sessions[1].PoliceNumber)
+		})
+	}
+}
+
+func TestHistory_ReceiptOnLeave(t *testing.T) {
+	now := clock.NewFake(time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC))
+	srv := server.CreateAppServer(backend.NewParkingService(backend.WithClock(now)))
+
+	_, _, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Data: "1"})
+	assert.NoError(t, err)
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdPark, XRequestId: "req-1", Data: types.CarDTO{PoliceNumber: "B1234ABC"}})
+	assert.NoError(t, err)
+
+	now.Advance(4 * time.Hour)
+	_, data, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdLeave, Data: types.CarDTO{PoliceNumber: "B1234ABC"}})
+	assert.NoError(t, err)
+
+	session, ok := data.(types.Session)
+	assert.True(t, ok)
+	assert.Equal(t, 4*time.Hour, session.Duration())
+
+	receipt := session.Receipt()
+	assert.True(t, strings.Contains(receipt, "B1234ABC"))
+	assert.True(t, strings.Contains(receipt, "total    : 30"))
+	assert.True(t, strings.Contains(receipt, "req-1"))
+
+	_, data, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdHistory, Data: map[string]any{"police_number": "B1234ABC"}})
+	assert.NoError(t, err)
+	assert.Len(t, data.([]types.Session), 1)
+}
+
+func TestHistory_ParseHistoryArgs(t *testing.T) {
+	query, err := extra.ParseHistoryArgs([]string{"B", "1234", "from=2024-05-01", "to=2024-05-02T12:00"})
+	assert.NoError(t, err)
+	assert.Equal(t, "B 1234", query.PoliceNumber)
+	assert.Equal(t, 36*time.Hour, query.To.Sub(query.From))
+
+	_, err = extra.ParseHistoryArgs([]string{"from=yesterday"})
+	assert.Error(t, err)
+}
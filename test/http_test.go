@@ -0,0 +1,78 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/server"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/khafidprayoga/parking-app/internal/wire"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP_ParkingFlow(t *testing.T) {
+	srv := httptest.NewServer(server.CreateAppServer(backend.NewParkingServiceBTree()).HTTPHandler())
+	defer srv.Close()
+
+	call := func(method, path, body string) (int, map[string]any) {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		assert.NoError(t, err)
+
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		decoded := map[string]any{}
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&decoded))
+		return res.StatusCode, decoded
+	}
+
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}{
+		{"Open lot", http.MethodPost, "/lots", `{"capacity":1}`, http.StatusCreated},
+		{"Open lot twice", http.MethodPost, "/lots", `{"capacity":2}`, http.StatusConflict},
+		{"Park car", http.MethodPost, "/cars", `{"police_number":"B1234ABC"}`, http.StatusCreated},
+		{"Park duplicate car", http.MethodPost, "/cars", `{"police_number":"B1234ABC"}`, http.StatusConflict},
+		{"Park on full lot", http.MethodPost, "/cars", `{"police_number":"B5678DEF"}`, http.StatusConflict},
+		{"Park with malformed body", http.MethodPost, "/cars", `{`, http.StatusBadRequest},
+		{"Park with body over frame size", http.MethodPost, "/cars", `{"police_number":"B5678DEF"` + strings.Repeat(" ", wire.MaxFrameSize) + `}`, http.StatusBadRequest},
+		{"Leave with invalid hours", http.MethodPost, "/cars/B1234ABC/leave", `{"hours":-1}`, http.StatusBadRequest},
+		{"Leave with hours override", http.MethodPost, "/cars/B1234ABC/leave", `{"hours":3}`, http.StatusBadRequest},
+		{"Leave car", http.MethodPost, "/cars/B1234ABC/leave", `{"method":"card"}`, http.StatusOK},
+		{"Leave unknown car", http.MethodPost, "/cars/B1234ABC/leave", `{}`, http.StatusNotFound},
+		{"Unknown car route", http.MethodPost, "/cars/B1234ABC/park", `{}`, http.StatusNotFound},
+		{"Find car wrong method", http.MethodPost, "/cars/B1234ABC", `{}`, http.StatusMethodNotAllowed},
+		{"Wrong method", http.MethodDelete, "/cars", ``, http.StatusMethodNotAllowed},
+		{"Refund unknown payment", http.MethodPost, "/payments/unknown/refund", ``, http.StatusNotFound},
+		{"Unknown payment route", http.MethodPost, "/payments/unknown/charge", ``, http.StatusNotFound},
+		{"Monthly report", http.MethodGet, "/report?period=monthly", ``, http.StatusOK},
+		{"Report of unknown period", http.MethodGet, "/report?period=weekly", ``, http.StatusBadRequest},
+		{"Report with malformed range", http.MethodGet, "/report?from=yesterday", ``, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, body := call(tc.method, tc.path, tc.body)
+			assert.Equal(t, tc.expectedCode, code, body)
+		})
+	}
+
+	res, err := http.Get(srv.URL + "/status")
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	statusData := types.AppStatus{}
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&statusData))
+	assert.Equal(t, 1, statusData.LotParkingCapacity)
+	assert.Equal(t, idr(10), statusData.Revenue)
+}
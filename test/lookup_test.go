@@ -0,0 +1,104 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/server"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup_FindCarAndSlot(t *testing.T) {
+	for name, newBackend := range allBackends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+
+			_, err := uc.FindCar("B1")
+			assert.ErrorIs(t, err, contract.ErrNotInitialized)
+			_, err = uc.SlotDetail(1)
+			assert.ErrorIs(t, err, contract.ErrNotInitialized)
+
+			assert.NoError(t, uc.OpenParkingArea(types.LotLayout{{Level: 1, Zone: "A", Class: types.ClassCar, Count: 3}}))
+			for _, plate := range []string{"B1", "B2", "B3"} {
+				_, err = uc.EnterArea(types.CarDTO{PoliceNumber: plate})
+				assert.NoError(t, err)
+			}
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B2", Hours: 1})
+			assert.NoError(t, err)
+
+			car, err := uc.FindCar("B3")
+			assert.NoError(t, err)
+			assert.Equal(t, 3, car.AreaNumber)
+			assert.Equal(t, "L1-A-3", car.SlotLabel)
+
+			_, err = uc.FindCar("B2")
+			assert.ErrorIs(t, err, contract.ErrCarNotFound)
+
+			detail, err := uc.SlotDetail(1)
+			assert.NoError(t, err)
+			assert.Equal(t, "L1-A-1", detail.Label)
+			assert.Equal(t, "B1", detail.Car.PoliceNumber)
+
+			detail, err = uc.SlotDetail(2)
+			assert.NoError(t, err)
+			assert.Nil(t, detail.Car)
+
+			// draining slot is still looked up until the car leave
+			assert.NoError(t, uc.ResizeParkingArea(types.UniformLayout(2), true))
+			detail, err = uc.SlotDetail(3)
+			assert.NoError(t, err)
+			assert.True(t, detail.Draining)
+			assert.Equal(t, "B3", detail.Car.PoliceNumber)
+
+			_, err = uc.SlotDetail(4)
+			assert.ErrorIs(t, err, contract.ErrSlotNotFound)
+			_, err = uc.SlotDetail(0)
+			assert.ErrorIs(t, err, contract.ErrSlotNotFound)
+		})
+	}
+}
+
+func TestLookup_SocketAndHTTP(t *testing.T) {
+	app := server.CreateAppServer(backend.NewParkingService(backend.WithSimulatedHours()))
+	_, _, err := app.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Data: "2"})
+	assert.NoError(t, err)
+	_, _, err = app.HandleIncomingMsg(types.Socket{Command: types.CmdPark, Data: types.CarDTO{PoliceNumber: "KA-01-HH-1234"}})
+	assert.NoError(t, err)
+
+	response, data, err := app.HandleIncomingMsg(types.Socket{Command: types.CmdFind, Data: types.CarDTO{PoliceNumber: "ka-01-hh-1234"}})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(response, "KA-01-HH-1234 is parked at slot 1"))
+	assert.Equal(t, 1, data.(types.Car).AreaNumber)
+
+	response, _, err = app.HandleIncomingMsg(types.Socket{Command: types.CmdSlot, Data: types.SlotDTO{Number: 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, "slot 2 (2, car) is free", response)
+
+	srv := httptest.NewServer(app.HTTPHandler())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/cars/KA-01-HH-1234")
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = http.Get(srv.URL + "/slots/1")
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	detail := types.SlotDetail{}
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&detail))
+	assert.Equal(t, "KA-01-HH-1234", detail.Car.PoliceNumber)
+
+	res, err = http.Get(srv.URL + "/cars/B404")
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
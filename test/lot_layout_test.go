@@ -0,0 +1,90 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/server"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLotLayout_LevelAndZoneLabel(t *testing.T) {
+	layout, err := types.ParseLotLayout("L2-A:car=2 L1-b:car=1 L1-A:moto=1")
+	assert.NoError(t, err)
+	assert.Equal(t, "L2-A:car=2 L1-B:car=1 L1-A:moto=1", layout.String())
+
+	// lowest level first, zone in the declared order
+	assert.Equal(t, []types.Slot{
+		{Number: 1, Label: "L1-B-1", Level: 1, Zone: "B", Class: types.ClassCar},
+		{Number: 2, Label: "L1-A-1", Level: 1, Zone: "A", Class: types.ClassMoto},
+		{Number: 3, Label: "L2-A-1", Level: 2, Zone: "A", Class: types.ClassCar},
+		{Number: 4, Label: "L2-A-2", Level: 2, Zone: "A", Class: types.ClassCar},
+	}, layout.Slots())
+
+	flat, err := types.ParseLotLayout("car=2")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", flat.Slots()[1].Label)
+
+	_, err = types.ParseLotLayout("L1-A-B:car=2")
+	assert.Error(t, err)
+}
+
+func TestLotLayout_AllocateLowestLevelAndFilter(t *testing.T) {
+	layout := types.LotLayout{
+		{Level: 2, Zone: "A", Class: types.ClassCar, Count: 2},
+		{Level: 1, Zone: "B", Class: types.ClassCar, Count: 1},
+		{Level: 1, Zone: "A", Class: types.ClassMoto, Count: 1},
+	}
+
+	for name, newBackend := range allBackends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+			assert.NoError(t, uc.OpenParkingArea(layout))
+
+			areaId, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B1CAR"})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, areaId)
+
+			// moto slot on level 1 is skipped, next car go up
+			areaId, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B2CAR"})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, areaId)
+
+			exitedCar, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B2CAR", Hours: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, "L2-A-1", exitedCar.SlotLabel)
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+
+			upper := statusData.Filter(types.StatusFilter{Level: 2})
+			assert.Len(t, upper.Slots, 2)
+			assert.Len(t, upper.CarList, 2)
+			assert.Equal(t, []types.ClassOccupancy{{Class: types.ClassCar, Capacity: 2}}, upper.Occupancy)
+
+			zoneA := statusData.Filter(types.StatusFilter{Zone: "a"})
+			assert.Len(t, zoneA.Slots, 3)
+
+			zoneB := statusData.Filter(types.StatusFilter{Level: 1, Zone: "B"})
+			assert.Equal(t, "B1CAR", zoneB.CarList[0].PoliceNumber)
+			assert.Equal(t, "L1-B-1", zoneB.CarList[0].SlotLabel)
+		})
+	}
+}
+
+func TestLotLayout_StatusFilterOverSocket(t *testing.T) {
+	srv := server.CreateAppServer(backend.NewParkingServiceBTree(backend.WithSimulatedHours()))
+
+	_, _, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Data: "L1:car=2 L2:car=3"})
+	assert.NoError(t, err)
+
+	_, data, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdStatus, Data: map[string]any{"level": 2}})
+	assert.NoError(t, err)
+
+	status, ok := data.(types.AppStatus)
+	assert.True(t, ok)
+	assert.Len(t, status.Slots, 3)
+	assert.Equal(t, "L2-1", status.Slots[0].Label)
+	assert.Equal(t, 5, status.LotParkingCapacity)
+}
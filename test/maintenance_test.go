@@ -0,0 +1,98 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/clock"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenance_DisableAndEnableSlot(t *testing.T) {
+	now := clock.NewFake(time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC))
+	backends := map[string]func() contract.IParkingUseCase{
+		"slice": func() contract.IParkingUseCase {
+			return backend.NewParkingService(backend.WithSimulatedHours(), backend.WithClock(now))
+		},
+		"btree": func() contract.IParkingUseCase {
+			return backend.NewParkingServiceBTree(backend.WithSimulatedHours(), backend.WithClock(now))
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+			assert.ErrorIs(t, uc.DisableSlot(1, "cleaning", false), contract.ErrNotInitialized)
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(3)))
+
+			_, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B1"})
+			assert.NoError(t, err)
+
+			assert.NoError(t, uc.DisableSlot(2, "cleaning", false))
+			areaId, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B2"})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, areaId)
+
+			// occupied slot need force, the car stay until it leave
+			assert.ErrorIs(t, uc.DisableSlot(1, "damaged", false), contract.ErrSlotOccupied)
+			assert.NoError(t, uc.DisableSlot(1, "damaged", true))
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B1", Hours: 1})
+			assert.NoError(t, err)
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B3"})
+			assert.ErrorIs(t, err, contract.ErrLotFull)
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, &types.Maintenance{Reason: "cleaning", Since: now.Now()}, statusData.Slots[1].Maintenance)
+			assert.Equal(t, []types.ClassOccupancy{{Class: types.ClassCar, Capacity: 3, Occupied: 1, Disabled: 2}}, statusData.Occupancy)
+
+			assert.NoError(t, uc.EnableSlot(2))
+			areaId, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B3"})
+			assert.NoError(t, err)
+			assert.Equal(t, 2, areaId)
+
+			// resize keep the maintenance of the remaining slot
+			assert.NoError(t, uc.ResizeParkingArea(types.UniformLayout(4), false))
+			areaId, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B4"})
+			assert.NoError(t, err)
+			assert.Equal(t, 4, areaId)
+
+			assert.ErrorIs(t, uc.DisableSlot(5, "", false), contract.ErrSlotNotFound)
+			assert.ErrorIs(t, uc.EnableSlot(0), contract.ErrSlotNotFound)
+		})
+	}
+}
+
+func TestMaintenance_RestoreDisabledSlot(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			before := newBackend(db)
+			assert.NoError(t, before.Restore())
+			assert.NoError(t, before.OpenParkingArea(types.UniformLayout(3)))
+			assert.NoError(t, before.DisableSlot(1, "cleaning", false))
+			assert.NoError(t, before.Compact())
+			assert.NoError(t, before.DisableSlot(2, "damaged", false))
+
+			after := newBackend(db)
+			assert.NoError(t, after.Restore())
+
+			statusData, err := after.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, "cleaning", statusData.Slots[0].Maintenance.Reason)
+			assert.Equal(t, "damaged", statusData.Slots[1].Maintenance.Reason)
+
+			areaId, err := after.EnterArea(types.CarDTO{PoliceNumber: "B1"})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, areaId)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/clock"
+	"github.com/khafidprayoga/parking-app/internal/extra"
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/tariff"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMembership_MonthlyPassParkFree(t *testing.T) {
+	start := time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)
+	now := clock.NewFake(start)
+	backends := map[string]func() contract.IParkingUseCase{
+		"slice": func() contract.IParkingUseCase {
+			return backend.NewParkingService(backend.WithSimulatedHours(), backend.WithClock(now))
+		},
+		"btree": func() contract.IParkingUseCase {
+			return backend.NewParkingServiceBTree(backend.WithSimulatedHours(), backend.WithClock(now))
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			now.Set(start)
+			uc := newBackend()
+			_, err := uc.AddMember(types.MemberDTO{PoliceNumber: "B1MEM"})
+			assert.ErrorIs(t, err, contract.ErrNotInitialized)
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(2)))
+
+			member, err := uc.AddMember(types.MemberDTO{PoliceNumber: "b1mem", Name: " Budi "})
+			assert.NoError(t, err)
+			assert.Equal(t, types.Member{PoliceNumber: "B1MEM", Name: "Budi", Since: start, ValidUntil: start.AddDate(0, 1, 0)}, member)
+
+			// early renewal extend from the current end
+			member, err = uc.AddMember(types.MemberDTO{PoliceNumber: "B1MEM", Months: 2})
+			assert.NoError(t, err)
+			assert.Equal(t, "Budi", member.Name)
+			assert.Equal(t, start.AddDate(0, 3, 0), member.ValidUntil)
+
+			for _, invalid := range []types.MemberDTO{{PoliceNumber: "B1MEM", Months: -1}, {PoliceNumber: "B1MEM", Months: 13}, {PoliceNumber: "B"}} {
+				_, err = uc.AddMember(invalid)
+				assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+			}
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B1MEM"})
+			assert.NoError(t, err)
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B2WALK"})
+			assert.NoError(t, err)
+			now.Advance(3 * time.Hour)
+
+			exitedCar, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B1MEM"})
+			assert.NoError(t, err)
+			assert.Equal(t, idr(0), exitedCar.Cost)
+			assert.Equal(t, idr(20), exitedCar.Discount)
+			assert.Equal(t, "monthly pass", exitedCar.DiscountReason)
+
+			exitedCar, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B2WALK"})
+			assert.NoError(t, err)
+			assert.Equal(t, idr(20), exitedCar.Cost)
+			assert.True(t, exitedCar.Discount.IsZero())
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, idr(20), statusData.Revenue)
+
+			// expired pass pay the full tariff
+			now.Advance(100 * 24 * time.Hour)
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B1MEM"})
+			assert.NoError(t, err)
+			exitedCar, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B1MEM", Hours: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, idr(10), exitedCar.Cost)
+
+			members, err := uc.Members()
+			assert.NoError(t, err)
+			assert.Len(t, members, 1)
+
+			assert.NoError(t, uc.RemoveMember("B-1-MEM"))
+			assert.ErrorIs(t, uc.RemoveMember("B1MEM"), contract.ErrMemberNotFound)
+
+			members, err = uc.Members()
+			assert.NoError(t, err)
+			assert.Empty(t, members)
+		})
+	}
+}
+
+func TestMembership_LoyaltyTier(t *testing.T) {
+	loyalty := tariff.Loyalty{{Visits: 2, Percent: 10}, {Visits: 4, Percent: 25}}
+	backends := map[string]contract.IParkingUseCase{
+		"slice": backend.NewParkingService(backend.WithSimulatedHours(), backend.WithLoyalty(loyalty)),
+		"btree": backend.NewParkingServiceBTree(backend.WithSimulatedHours(), backend.WithLoyalty(loyalty)),
+	}
+
+	for name, uc := range backends {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(1)))
+
+			costs := []money.Money{}
+			for i := 0; i < 5; i++ {
+				_, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B1LOYAL"})
+				assert.NoError(t, err)
+				exitedCar, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B1LOYAL", Hours: 3})
+				assert.NoError(t, err)
+				costs = append(costs, exitedCar.Cost)
+			}
+
+			// first and second visit pay full, then 10% after 2 visit and 25% after 4 visit
+			assert.Equal(t, []money.Money{idr(20), idr(20), idr(18), idr(18), idr(15)}, costs)
+
+			sessions, err := uc.History(types.HistoryQuery{})
+			assert.NoError(t, err)
+			assert.Equal(t, "loyalty 25% after 4 visit", sessions[4].DiscountReason)
+
+			receipt := sessions[4].Receipt()
+			assert.True(t, strings.Contains(receipt, "subtotal : 20 IDR"))
+			assert.True(t, strings.Contains(receipt, "discount : 5 IDR (loyalty 25% after 4 visit)"))
+			assert.True(t, strings.Contains(receipt, "total    : 15 IDR"))
+		})
+	}
+}
+
+func TestMembership_LoyaltyConfig(t *testing.T) {
+	rule, err := tariff.NewRuleSet(tariff.Config{BaseFee: plain(10), BaseHours: 2, HourlyRate: plain(10), Loyalty: tariff.Loyalty{{Visits: 10, Percent: 20}, {Visits: 5, Percent: 10}}})
+	assert.NoError(t, err)
+
+	tier, ok := rule.Loyalty().TierOf(7)
+	assert.True(t, ok)
+	assert.Equal(t, 10.0, tier.Percent)
+
+	_, ok = rule.Loyalty().TierOf(4)
+	assert.False(t, ok)
+
+	for _, invalid := range []tariff.Loyalty{
+		{{Visits: 0, Percent: 10}},
+		{{Visits: 5, Percent: 0}},
+		{{Visits: 5, Percent: 120}},
+		{{Visits: 5, Percent: 10}, {Visits: 5, Percent: 20}},
+	} {
+		_, err = tariff.NewRuleSet(tariff.Config{Loyalty: invalid})
+		assert.Error(t, err)
+	}
+}
+
+func TestMembership_RestoreMember(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			before := newBackend(db)
+			assert.NoError(t, before.Restore())
+			assert.NoError(t, before.OpenParkingArea(types.UniformLayout(2)))
+			_, err = before.AddMember(types.MemberDTO{PoliceNumber: "B1MEM"})
+			assert.NoError(t, err)
+			assert.NoError(t, before.Compact())
+			_, err = before.AddMember(types.MemberDTO{PoliceNumber: "B2MEM"})
+			assert.NoError(t, err)
+			_, err = before.AddMember(types.MemberDTO{PoliceNumber: "B3MEM"})
+			assert.NoError(t, err)
+			assert.NoError(t, before.RemoveMember("B3MEM"))
+
+			_, err = before.EnterArea(types.CarDTO{PoliceNumber: "B2MEM"})
+			assert.NoError(t, err)
+			_, err = before.LeaveArea(types.CarDTO{PoliceNumber: "B2MEM", Hours: 2})
+			assert.NoError(t, err)
+
+			after := newBackend(db)
+			assert.NoError(t, after.Restore())
+
+			members, err := after.Members()
+			assert.NoError(t, err)
+			assert.Len(t, members, 2)
+			assert.Equal(t, "B1MEM", members[0].PoliceNumber)
+			assert.Equal(t, "B2MEM", members[1].PoliceNumber)
+
+			sessions, err := after.History(types.HistoryQuery{})
+			assert.NoError(t, err)
+			assert.Equal(t, idr(10), sessions[0].Discount)
+			assert.True(t, sessions[0].Cost.IsZero())
+		})
+	}
+}
+
+func TestMembership_ParseMemberArgs(t *testing.T) {
+	member, err := extra.ParseMemberArgs([]string{"b", "1234", "abc", "months=3", "name=Budi"})
+	assert.NoError(t, err)
+	assert.Equal(t, types.MemberDTO{PoliceNumber: "B 1234 ABC", Months: 3, Name: "Budi"}, member)
+
+	_, err = extra.ParseMemberArgs([]string{"B1234ABC", "months=three"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/tariff"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// plain is amount without currency, e.g. tariff fee and refund request
+func plain(units int64) money.Money {
+	return money.FromUnits(units, "")
+}
+
+// idr is amount in the default currency
+func idr(units int64) money.Money {
+	return money.FromUnits(units, types.DefaultCurrency)
+}
+
+func TestMoney_ParseRoundPerCurrency(t *testing.T) {
+	testCases := []struct {
+		amount   string
+		currency string
+		expected money.Money
+		text     string
+	}{
+		{amount: "10", currency: "USD", expected: money.FromMinor(1000, "USD"), text: "10.00 USD"},
+		{amount: "10.005", currency: "USD", expected: money.FromMinor(1001, "USD"), text: "10.01 USD"},
+		{amount: "-10.005", currency: "USD", expected: money.FromMinor(-1001, "USD"), text: "-10.01 USD"},
+		{amount: "10.004", currency: "USD", expected: money.FromMinor(1000, "USD"), text: "10.00 USD"},
+		{amount: "2500.5", currency: "IDR", expected: money.FromMinor(2501, "IDR"), text: "2501 IDR"},
+		{amount: "0.4", currency: "IDR", expected: money.FromMinor(0, "IDR"), text: "0 IDR"},
+		{amount: "1.2345", currency: "KWD", expected: money.FromMinor(1235, "KWD"), text: "1.235 KWD"},
+		{amount: ".5", currency: "", expected: money.FromMinor(50, ""), text: "0.50"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.amount+" "+tc.currency, func(t *testing.T) {
+			amount, err := money.Parse(tc.amount, tc.currency)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, amount)
+			assert.Equal(t, tc.text, amount.String())
+		})
+	}
+
+	for _, malformed := range []string{"", "-", "1.2.3", "1e3", "ten", "--1", "1,5"} {
+		_, err := money.Parse(malformed, "USD")
+		assert.ErrorIs(t, err, money.ErrMalformed, malformed)
+	}
+}
+
+func TestMoney_ArithmeticStayExact(t *testing.T) {
+	// ten times 0.10 is exactly 1.00, float64 end at 0.9999999999999999
+	total := money.Money{}
+	for i := 0; i < 10; i++ {
+		total = total.Add(money.FromMinor(10, "USD"))
+	}
+	assert.Equal(t, money.FromUnits(1, "USD"), total)
+
+	// plain amount take the currency of the other rounded to its minor unit
+	assert.Equal(t, money.FromMinor(13, "IDR"), money.FromMinor(1250, "").Add(idr(0)))
+	assert.Equal(t, money.FromMinor(-13, "IDR"), money.FromMinor(-1250, "").In("IDR"))
+	assert.Equal(t, money.FromMinor(2, "IDR"), idr(15).Percent(12.5))
+	assert.Equal(t, 1, idr(20).Cmp(plain(19)))
+	assert.Equal(t, money.FromMinor(700, "USD"), money.FromMinor(1000, "USD").Sub(plain(3)))
+	assert.Panics(t, func() { idr(1).Add(money.FromUnits(1, "USD")) })
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(money.FromMinor(1050, "USD"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount": "10.50", "currency": "USD"}`, string(data))
+
+	data, err = json.Marshal(plain(10))
+	assert.NoError(t, err)
+	assert.Equal(t, `10.00`, string(data))
+
+	testCases := map[string]money.Money{
+		`{"amount": "10.50", "currency": "USD"}`: money.FromMinor(1050, "USD"),
+		`{"amount": 2500, "currency": "IDR"}`:    idr(2500),
+		`"20 IDR"`:                               idr(20),
+		`12.5`:                                   money.FromMinor(1250, ""),
+		`null`:                                   {},
+	}
+	for raw, expected := range testCases {
+		amount := money.Money{}
+		assert.NoError(t, json.Unmarshal([]byte(raw), &amount), raw)
+		assert.Equal(t, expected, amount, raw)
+	}
+
+	assert.Error(t, json.Unmarshal([]byte(`"ten"`), &money.Money{}))
+}
+
+func TestMoney_FractionalTariffInCurrency(t *testing.T) {
+	rule, err := tariff.NewRuleSet(tariff.Config{HourlyRate: money.FromMinor(250, "")})
+	assert.NoError(t, err)
+
+	_, err = tariff.NewRuleSet(tariff.Config{HourlyRate: money.FromUnits(2, "USD")})
+	assert.Error(t, err)
+
+	testCases := []struct {
+		currency string
+		expected money.Money
+	}{
+		{currency: "USD", expected: money.FromMinor(750, "USD")},
+		{currency: "IDR", expected: money.FromMinor(8, "IDR")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.currency, func(t *testing.T) {
+			uc := backend.NewParkingService(backend.WithSimulatedHours(), backend.WithTariff(rule), backend.WithCurrency(tc.currency))
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(1)))
+			_, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B1"})
+			assert.NoError(t, err)
+
+			exitedCar, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B1", Hours: 3})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, exitedCar.Cost)
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, statusData.Revenue)
+		})
+	}
+}
+
+func TestMoney_RestorePlainAmountAndRefuseOtherCurrency(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			// state written before money had currency
+			db, err := store.NewFileStore(dir)
+			assert.NoError(t, err)
+			legacy := []byte(`{"lot_capacity": 1, "car_list": [null], "revenue": 12.5,
+				"sessions": [{"police_number": "B1", "cost": 12.5}],
+				"ledger": [{"id": "p1", "kind": "payment", "police_number": "B1", "debit": "cash", "credit": "revenue", "amount": 12.5, "currency": "IDR"}]}`)
+			state := store.Snapshot{}
+			assert.NoError(t, json.Unmarshal(legacy, &state))
+			assert.NoError(t, db.Save(state))
+
+			uc := newBackend(db)
+			assert.NoError(t, uc.Restore())
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, idr(13), statusData.Revenue)
+
+			sessions, err := uc.History(types.HistoryQuery{})
+			assert.NoError(t, err)
+			assert.Equal(t, idr(13), sessions[0].Cost)
+			assert.NoError(t, uc.Compact())
+			assert.NoError(t, db.Close())
+
+			// the compacted state is priced in IDR and refused by USD server
+			db, err = store.NewFileStore(dir)
+			assert.NoError(t, err)
+			defer db.Close()
+
+			usd := backend.NewParkingService(backend.WithSimulatedHours(), backend.WithStore(db), backend.WithCurrency("usd"))
+			assert.Error(t, usd.Restore())
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/extra"
+	"github.com/khafidprayoga/parking-app/internal/server"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMultiLotServer() *server.ParkingAppServer {
+	return server.CreateMultiLotServer(nil, func(lot string) (contract.IParkingUseCase, error) {
+		return backend.NewParkingServiceBTree(backend.WithSimulatedHours()), nil
+	}, nil)
+}
+
+func TestMultiLot_IndependentLots(t *testing.T) {
+	srv := newMultiLotServer()
+
+	_, _, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Lot: "north", Data: "1"})
+	assert.NoError(t, err)
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Lot: "south", Data: "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"north", "south"}, srv.Lots())
+
+	// same car can not be at two lots, but every lot only know its own cars
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdPark, Lot: "north", Data: types.CarDTO{PoliceNumber: "B1234ABC"}})
+	assert.NoError(t, err)
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdPark, Lot: "north", Data: types.CarDTO{PoliceNumber: "B5678DEF"}})
+	assert.Equal(t, types.ErrCodeLotFull, contract.CodeOf(err))
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdPark, Lot: "south", Data: types.CarDTO{PoliceNumber: "B5678DEF"}})
+	assert.NoError(t, err)
+
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdLeave, Lot: "north", Data: types.CarDTO{PoliceNumber: "B1234ABC", Hours: 3}})
+	assert.NoError(t, err)
+
+	_, data, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdStatus, Lot: "north"})
+	assert.NoError(t, err)
+	assert.Equal(t, idr(20), data.(types.AppStatus).Revenue)
+
+	_, data, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdStatus, Lot: "south"})
+	assert.NoError(t, err)
+	assert.Equal(t, idr(0), data.(types.AppStatus).Revenue)
+	assert.Equal(t, "B5678DEF", data.(types.AppStatus).CarList[0].PoliceNumber)
+
+	// only create_parking_lot bring up new lot
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdPark, Lot: "east", Data: types.CarDTO{PoliceNumber: "B1234ABC"}})
+	assert.ErrorIs(t, err, contract.ErrLotNotFound)
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdStatus})
+	assert.Equal(t, types.ErrCodeLotNotFound, contract.CodeOf(err))
+
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Lot: "../etc", Data: "1"})
+	assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+}
+
+func TestMultiLot_RejectedCreateIsDiscarded(t *testing.T) {
+	discarded := []string{}
+	srv := server.CreateMultiLotServer(nil, func(lot string) (contract.IParkingUseCase, error) {
+		return backend.NewParkingService(backend.WithSimulatedHours()), nil
+	}, func(lot string) {
+		discarded = append(discarded, lot)
+	})
+
+	_, _, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Lot: "west", Data: "0"})
+	assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+	assert.Equal(t, []string{"west"}, discarded)
+	assert.Empty(t, srv.Lots())
+
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdStatus, Lot: "west"})
+	assert.ErrorIs(t, err, contract.ErrLotNotFound)
+
+	// the name is free for the next valid create
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Lot: "west", Data: "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"west"}, srv.Lots())
+	assert.Len(t, discarded, 1)
+}
+
+func TestMultiLot_CreateDoNotBlockOtherLots(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	var created int32
+	srv := server.CreateMultiLotServer(map[string]contract.IParkingUseCase{"north": backend.NewParkingService()}, func(lot string) (contract.IParkingUseCase, error) {
+		if atomic.AddInt32(&created, 1) == 1 {
+			close(entered)
+			<-release
+		}
+		return backend.NewParkingService(), nil
+	}, nil)
+
+	_, _, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Lot: "north", Data: "1"})
+	assert.NoError(t, err)
+
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, _, errCreate := srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Lot: "south", Data: "1"})
+			results <- errCreate
+		}()
+	}
+	<-entered
+
+	// the slow create of south hold no lock the other lot need
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdPark, Lot: "north", Data: types.CarDTO{PoliceNumber: "B1234ABC"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"north"}, srv.Lots())
+
+	close(release)
+	errs := []error{<-results, <-results}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&created))
+	assert.Equal(t, []string{"north", "south"}, srv.Lots())
+	if errs[0] == nil {
+		errs[0], errs[1] = errs[1], errs[0]
+	}
+	assert.ErrorIs(t, errs[0], contract.ErrAlreadyInitialized)
+	assert.NoError(t, errs[1])
+}
+
+func TestMultiLot_SingleLotServer(t *testing.T) {
+	srv := server.CreateAppServer(backend.NewParkingService(backend.WithSimulatedHours()))
+
+	_, _, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Lot: types.DefaultLot, Data: "1"})
+	assert.NoError(t, err)
+
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Lot: "north", Data: "1"})
+	assert.ErrorIs(t, err, contract.ErrLotNotFound)
+}
+
+func TestMultiLot_HTTPLotQuery(t *testing.T) {
+	srv := httptest.NewServer(newMultiLotServer().HTTPHandler())
+	defer srv.Close()
+
+	post := func(path, body string) int {
+		res, err := http.Post(srv.URL+path, "application/json", strings.NewReader(body))
+		assert.NoError(t, err)
+		defer res.Body.Close()
+		return res.StatusCode
+	}
+
+	assert.Equal(t, http.StatusCreated, post("/lots", `{"lot":"north","capacity":1}`))
+	assert.Equal(t, http.StatusCreated, post("/cars?lot=north", `{"police_number":"B1234ABC"}`))
+	assert.Equal(t, http.StatusNotFound, post("/cars?lot=south", `{"police_number":"B1234ABC"}`))
+	assert.Equal(t, http.StatusOK, post("/cars/B1234ABC/leave?lot=north", ``))
+}
+
+func TestMultiLot_ExtractLot(t *testing.T) {
+	lot, rest := extra.ExtractLot([]string{"--lot", "north", "B1234ABC", "class=moto"})
+	assert.Equal(t, "north", lot)
+	assert.Equal(t, []string{"B1234ABC", "class=moto"}, rest)
+
+	lot, rest = extra.ExtractLot([]string{"car=20", "--lot=south"})
+	assert.Equal(t, "south", lot)
+	assert.Equal(t, []string{"car=20"}, rest)
+
+	lot, _ = extra.ExtractLot([]string{"6"})
+	assert.Equal(t, "", lot)
+}
@@ -1,8 +1,8 @@
 package test
 
 import (
-	"encoding/json"
 	"github.com/khafidprayoga/parking-app/internal/backend"
+	"strconv"
 	"testing"
 
 	"github.com/khafidprayoga/parking-app/contract"
@@ -16,7 +16,7 @@ type TestParkingService struct {
 
 func setupTestParkingService() *TestParkingService {
 	return &TestParkingService{
-		service: backend.NewParkingServiceBTree(),
+		service: backend.NewParkingServiceBTree(backend.WithSimulatedHours()),
 	}
 }
 
@@ -26,13 +26,13 @@ func TestParkingService_OpenArea(t *testing.T) {
 	expectedAreaCapacity := 6
 
 	// Test
-	err := testService.service.OpenParkingArea(expectedAreaCapacity)
+	err := testService.service.OpenParkingArea(types.UniformLayout(expectedAreaCapacity))
 
 	// Assertions
 	assert.NoError(t, err)
 
 	// overriding the current active area will thrown error
-	err = testService.service.OpenParkingArea(5)
+	err = testService.service.OpenParkingArea(types.UniformLayout(5))
 	assert.Error(t, err)
 }
 
@@ -40,7 +40,7 @@ func TestParkingService_EnterArea(t *testing.T) {
 	// Setup
 	testService := setupTestParkingService()
 	expectedAreaCapacity := 6
-	err := testService.service.OpenParkingArea(expectedAreaCapacity)
+	err := testService.service.OpenParkingArea(types.UniformLayout(expectedAreaCapacity))
 	assert.NoError(t, err)
 
 	// Test cases
@@ -96,7 +96,7 @@ func TestParkingService_LeaveArea(t *testing.T) {
 	// Setup
 	testService := setupTestParkingService()
 	expectedAreaCapacity := 6
-	err := testService.service.OpenParkingArea(expectedAreaCapacity)
+	err := testService.service.OpenParkingArea(types.UniformLayout(expectedAreaCapacity))
 	assert.NoError(t, err)
 
 	// Park a car first
@@ -152,7 +152,7 @@ func TestParkingService_Status(t *testing.T) {
 	// Setup
 	testService := setupTestParkingService()
 	expectedAreaCapacity := 6
-	err := testService.service.OpenParkingArea(expectedAreaCapacity)
+	err := testService.service.OpenParkingArea(types.UniformLayout(expectedAreaCapacity))
 	assert.NoError(t, err)
 
 	// Park some cars
@@ -173,14 +173,11 @@ func TestParkingService_Status(t *testing.T) {
 	}
 
 	// Test
-	status, err := testService.service.Status()
+	statusData, err := testService.service.Status()
 	assert.NoError(t, err)
-	assert.NotEmpty(t, status)
 
 	// Verify status contains expected information
-	var statusData types.AppStatus
 	carCount := 0
-	err = json.Unmarshal(status, &statusData)
 
 	for _, c := range statusData.CarList {
 		if c != nil {
@@ -227,7 +224,7 @@ func TestParkingService_EntryPoint(t *testing.T) {
 
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
-				err := testService.service.OpenParkingArea(tc.capacity)
+				err := testService.service.OpenParkingArea(types.UniformLayout(tc.capacity))
 				if tc.expectError {
 					assert.Error(t, err)
 				} else {
@@ -333,7 +330,7 @@ func TestParkingService_EntryPoint(t *testing.T) {
 					PoliceNumber: "B5678DEF",
 					Hours:        0,
 				},
-				expectError: true,
+				expectError: false,
 			},
 		}
 
@@ -356,15 +353,10 @@ func TestParkingService_EntryPoint(t *testing.T) {
 	// Test cases untuk Status
 	t.Run("Status Edge Cases", func(t *testing.T) {
 		// Test status setelah beberapa operasi
-		status, err := testService.service.Status()
-		assert.NoError(t, err)
-		assert.NotEmpty(t, status)
-
-		var statusData types.AppStatus
-		err = json.Unmarshal(status, &statusData)
+		statusData, err := testService.service.Status()
 		assert.NoError(t, err)
 		assert.Equal(t, 6, statusData.LotParkingCapacity)
-		assert.GreaterOrEqual(t, statusData.Revenue, 0.0)
+		assert.GreaterOrEqual(t, statusData.Revenue.Sign(), 0)
 		assert.GreaterOrEqual(t, statusData.TxCount, 0)
 	})
 
@@ -373,10 +365,10 @@ func TestParkingService_EntryPoint(t *testing.T) {
 	// Test cases untuk Full Capacity
 	t.Run("Full Capacity Edge Cases", func(t *testing.T) {
 		// Coba parkir mobil sampai penuh
-		for i := 3; i <= 7; i++ {
+		for i := 3; i <= 8; i++ {
 			car := types.CarDTO{
-				RequestId:    "req-" + string(rune(i)),
-				PoliceNumber: "B" + string(rune(i)) + "XYZ",
+				RequestId:    "req-" + strconv.Itoa(i),
+				PoliceNumber: "B" + strconv.Itoa(i) + "XYZ",
 			}
 			_, err := testService.service.EnterArea(car)
 			assert.NoError(t, err)
@@ -396,7 +388,7 @@ func TestParkingService_EntryPoint(t *testing.T) {
 	t.Run("Concurrent Operations", func(t *testing.T) {
 		// Reset service
 		testService = setupTestParkingService()
-		err := testService.service.OpenParkingArea(6)
+		err := testService.service.OpenParkingArea(types.UniformLayout(6))
 		assert.NoError(t, err)
 
 		// Test concurrent enter operations
@@ -404,8 +396,8 @@ func TestParkingService_EntryPoint(t *testing.T) {
 		for i := 0; i < 3; i++ {
 			go func(id int) {
 				car := types.CarDTO{
-					RequestId:    "req-concurrent-" + string(rune(id)),
-					PoliceNumber: "B" + string(rune(id)) + "CONC",
+					RequestId:    "req-concurrent-" + strconv.Itoa(id),
+					PoliceNumber: "B" + strconv.Itoa(id) + "CONC",
 				}
 				_, err := testService.service.EnterArea(car)
 				assert.NoError(t, err)
@@ -419,12 +411,9 @@ func TestParkingService_EntryPoint(t *testing.T) {
 		}
 
 		// Verify final state
-		status, err := testService.service.Status()
+		statusData, err := testService.service.Status()
 		assert.NoError(t, err)
 		carCount := 0
-		var statusData types.AppStatus
-		err = json.Unmarshal(status, &statusData)
-		assert.NoError(t, err)
 
 		for _, c := range statusData.CarList {
 			if c != nil {
@@ -0,0 +1,205 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/extra"
+	"github.com/khafidprayoga/parking-app/internal/money"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayment_LedgerRefundAndVoid(t *testing.T) {
+	for name, newBackend := range allBackends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+			_, err := uc.Payments(types.PaymentQuery{})
+			assert.ErrorIs(t, err, contract.ErrNotInitialized)
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(3)))
+
+			for _, plate := range []string{"B1", "B2", "B3"} {
+				_, err = uc.EnterArea(types.CarDTO{PoliceNumber: plate})
+				assert.NoError(t, err)
+			}
+
+			// unknown method leave the car parked
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B1", Hours: 3, Method: "cheque"})
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+
+			cash, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B1", Hours: 3})
+			assert.NoError(t, err)
+			assert.Equal(t, types.PaymentCash, cash.Method)
+			card, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B2", Hours: 4, Method: "Card"})
+			assert.NoError(t, err)
+			assert.Equal(t, types.PaymentCard, card.Method)
+			wallet, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B3", Hours: 1, Method: types.PaymentEWallet})
+			assert.NoError(t, err)
+
+			report, err := uc.Payments(types.PaymentQuery{})
+			assert.NoError(t, err)
+			assert.Len(t, report.Payments, 3)
+			assert.Equal(t, idr(60), report.Revenue)
+			assert.Equal(t, map[string]money.Money{types.PaymentCash: idr(20), types.PaymentCard: idr(30), types.PaymentEWallet: idr(10)}, report.Collected)
+
+			// partial refund then the rest
+			payment, err := uc.Refund(types.RefundDTO{PaymentId: card.PaymentId, Amount: plain(5), Reason: "wrong slot"})
+			assert.NoError(t, err)
+			assert.Equal(t, types.PaymentPartiallyRefunded, payment.Status)
+			assert.Equal(t, idr(25), payment.Remaining())
+
+			_, err = uc.Refund(types.RefundDTO{PaymentId: card.PaymentId, Amount: plain(30)})
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+			_, err = uc.Void(types.RefundDTO{PaymentId: card.PaymentId})
+			assert.ErrorIs(t, err, contract.ErrPaymentSettled)
+
+			payment, err = uc.Refund(types.RefundDTO{PaymentId: card.PaymentId})
+			assert.NoError(t, err)
+			assert.Equal(t, types.PaymentRefunded, payment.Status)
+			assert.Equal(t, idr(30), payment.Refunded)
+
+			_, err = uc.Refund(types.RefundDTO{PaymentId: card.PaymentId, Amount: plain(1)})
+			assert.ErrorIs(t, err, contract.ErrPaymentSettled)
+
+			payment, err = uc.Void(types.RefundDTO{PaymentId: wallet.PaymentId, Reason: "duplicate charge"})
+			assert.NoError(t, err)
+			assert.Equal(t, types.PaymentVoided, payment.Status)
+			_, err = uc.Refund(types.RefundDTO{PaymentId: wallet.PaymentId})
+			assert.ErrorIs(t, err, contract.ErrPaymentSettled)
+
+			_, err = uc.Refund(types.RefundDTO{PaymentId: "unknown"})
+			assert.ErrorIs(t, err, contract.ErrPaymentNotFound)
+
+			report, err = uc.Payments(types.PaymentQuery{PoliceNumber: "b1"})
+			assert.NoError(t, err)
+			assert.Len(t, report.Payments, 1)
+			assert.Equal(t, cash.PaymentId, report.Payments[0].Id)
+			assert.Equal(t, idr(20), report.Revenue)
+			assert.Equal(t, map[string]money.Money{types.PaymentCash: idr(20), types.PaymentCard: idr(0), types.PaymentEWallet: idr(0)}, report.Collected)
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, idr(20), statusData.Revenue)
+		})
+	}
+}
+
+func TestPayment_LedgerBalance(t *testing.T) {
+	ledger := types.Ledger{
+		{Id: "p1", Kind: types.EntryPayment, PoliceNumber: "B1", Debit: types.PaymentCash, Credit: types.AccountRevenue, Amount: idr(20)},
+		{Id: "p2", Kind: types.EntryPayment, PoliceNumber: "B2", Debit: types.PaymentCard, Credit: types.AccountRevenue, Amount: idr(10)},
+		{Id: "r1", PaymentId: "p1", Kind: types.EntryRefund, Debit: types.AccountRevenue, Credit: types.PaymentCash, Amount: idr(5)},
+	}
+
+	total := money.Money{}
+	for _, balance := range ledger.Balances() {
+		total = total.Add(balance)
+	}
+	assert.True(t, total.IsZero())
+	assert.Equal(t, idr(25), ledger.Revenue())
+
+	payment, ok := ledger.Payment("p1")
+	assert.True(t, ok)
+	assert.Equal(t, types.PaymentPartiallyRefunded, payment.Status)
+	assert.Equal(t, idr(15), payment.Remaining())
+}
+
+func TestPayment_RestoreLedger(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			before := newBackend(db)
+			assert.NoError(t, before.Restore())
+			assert.NoError(t, before.OpenParkingArea(types.UniformLayout(2)))
+			for _, plate := range []string{"B1", "B2"} {
+				_, err = before.EnterArea(types.CarDTO{PoliceNumber: plate})
+				assert.NoError(t, err)
+			}
+
+			first, err := before.LeaveArea(types.CarDTO{PoliceNumber: "B1", Hours: 3, Method: types.PaymentCard})
+			assert.NoError(t, err)
+			_, err = before.Refund(types.RefundDTO{RequestId: first.PaymentId, PaymentId: first.PaymentId, Amount: plain(5)})
+			assert.NoError(t, err)
+			assert.NoError(t, before.Compact())
+
+			// client request id is kept beside the entry id, it can not pose as the payment
+			state, _, err := db.Load()
+			assert.NoError(t, err)
+			assert.Len(t, state.Ledger, 2)
+			assert.Equal(t, first.PaymentId, state.Ledger[1].RequestId)
+			assert.NotEqual(t, first.PaymentId, state.Ledger[1].Id)
+
+			second, err := before.LeaveArea(types.CarDTO{PoliceNumber: "B2", Hours: 1})
+			assert.NoError(t, err)
+			_, err = before.Void(types.RefundDTO{PaymentId: second.PaymentId})
+			assert.NoError(t, err)
+
+			expected, err := before.Payments(types.PaymentQuery{})
+			assert.NoError(t, err)
+
+			after := newBackend(db)
+			assert.NoError(t, after.Restore())
+
+			report, err := after.Payments(types.PaymentQuery{})
+			assert.NoError(t, err)
+			assert.Len(t, report.Payments, 2)
+			for i, payment := range report.Payments {
+				assert.Equal(t, expected.Payments[i].Id, payment.Id)
+				assert.Equal(t, expected.Payments[i].Status, payment.Status)
+				assert.Equal(t, expected.Payments[i].Refunded, payment.Refunded)
+			}
+			assert.Equal(t, expected.Collected, report.Collected)
+			assert.Equal(t, idr(15), report.Revenue)
+		})
+	}
+}
+
+func TestPayment_OpeningBalanceOfLegacySnapshot(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			// snapshot written before the ledger only has the running revenue
+			assert.NoError(t, db.Save(store.Snapshot{LotCapacity: 1, CarList: make([]*types.Car, 1), Revenue: plain(50), Tx: map[string]int{"B1": 5}}))
+
+			uc := newBackend(db)
+			assert.NoError(t, uc.Restore())
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, idr(50), statusData.Revenue)
+
+			report, err := uc.Payments(types.PaymentQuery{})
+			assert.NoError(t, err)
+			assert.Empty(t, report.Payments)
+			assert.Equal(t, idr(50), report.Revenue)
+		})
+	}
+}
+
+func TestPayment_ParseArgs(t *testing.T) {
+	car, err := extra.ParseLeaveArgs([]string{"B", "1234", "hours=2", "method=card"})
+	assert.NoError(t, err)
+	assert.Equal(t, types.CarDTO{PoliceNumber: "B 1234", Hours: 2, Method: "card"}, car)
+
+	refund, err := extra.ParseRefundArgs([]string{"pay-1", "5.5", "wrong", "slot"}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, types.RefundDTO{PaymentId: "pay-1", Amount: money.FromMinor(550, ""), Reason: "wrong slot"}, refund)
+
+	refund, err = extra.ParseRefundArgs([]string{"pay-1", "2", "duplicate"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, types.RefundDTO{PaymentId: "pay-1", Reason: "2 duplicate"}, refund)
+
+	query, err := extra.ParsePaymentArgs([]string{"b1", "method=cash"})
+	assert.NoError(t, err)
+	assert.Equal(t, types.PaymentQuery{PoliceNumber: "B1", Method: "cash"}, query)
+
+	_, err = extra.ParseRefundArgs(nil, true)
+	assert.Error(t, err)
+}
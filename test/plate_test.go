@@ -0,0 +1,154 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/extra"
+	"github.com/khafidprayoga/parking-app/internal/plate"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/tariff"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlate_Normalize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		key      string
+		wantErr  bool
+	}{
+		{"Indonesian with space", " b  1234 abc ", "B 1234 ABC", "B1234ABC", false},
+		{"Indonesian compact", "b1234abc", "B1234ABC", "B1234ABC", false},
+		{"Indian with dash", "ka - 01-hh-1234", "KA-01-HH-1234", "KA01HH1234", false},
+		{"Dotted", "ab.123", "AB.123", "AB123", false},
+		{"Empty", "", "", "", true},
+		{"Single character", "B", "", "B", true},
+		{"Invalid character", "B1234/ABC", "", "B1234ABC", true},
+		{"Control character", "B\x03XYZ", "", "BXYZ", true},
+		{"Too long", "ABCDEFGHIJKLM", "", "ABCDEFGHIJKLM", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			display, err := plate.Normalize(tc.input)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, plate.ErrMalformed)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, display)
+			}
+			assert.Equal(t, tc.key, plate.Key(tc.input))
+		})
+	}
+
+	assert.True(t, plate.Equal("B 1234 ABC", "b-1234-abc"))
+	assert.False(t, plate.Equal("B 1234 ABC", "B 1234 ABD"))
+}
+
+func TestPlate_SameVehicleOnEveryFormat(t *testing.T) {
+	for name, newBackend := range allBackends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(2)))
+
+			areaId, err := uc.EnterArea(types.CarDTO{PoliceNumber: "ka-01"})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, areaId)
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "b 1234  abc"})
+			assert.NoError(t, err)
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B-1234-ABC"})
+			assert.ErrorIs(t, err, contract.ErrAlreadyParked)
+
+			car, err := uc.FindCar("B1234ABC")
+			assert.NoError(t, err)
+			assert.Equal(t, "B 1234 ABC", car.PoliceNumber)
+
+			exitedCar, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "KA-01", Hours: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, "KA-01", exitedCar.PoliceNumber)
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B@1234"})
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+		})
+	}
+}
+
+func TestPlate_ParseArgs(t *testing.T) {
+	car, err := extra.ParseLeaveArgs([]string{"b", "1234", "abc", "hours=2"})
+	assert.NoError(t, err)
+	assert.Equal(t, types.CarDTO{PoliceNumber: "B 1234 ABC", Hours: 2}, car)
+
+	// trailing number of a spaced plate is part of the plate, never the hours
+	car, err = extra.ParseLeaveArgs([]string{"B", "1234"})
+	assert.NoError(t, err)
+	assert.Equal(t, types.CarDTO{PoliceNumber: "B 1234"}, car)
+
+	car, err = extra.ParseLeaveArgs([]string{"KA-01-HH-1234", "4"})
+	assert.NoError(t, err)
+	assert.Equal(t, types.CarDTO{PoliceNumber: "KA-01-HH-1234", Hours: 4}, car)
+
+	_, err = extra.ParseLeaveArgs([]string{"B1234", "hours=two"})
+	assert.Error(t, err)
+
+	car, err = extra.ParseParkArgs([]string{"ka-01-hh-1234", "class=moto"})
+	assert.NoError(t, err)
+	assert.Equal(t, "KA-01-HH-1234", car.PoliceNumber)
+
+	_, err = extra.ParseParkArgs([]string{"B#1234"})
+	assert.ErrorIs(t, err, plate.ErrMalformed)
+
+	_, err = extra.ParsePlate(nil)
+	assert.Error(t, err)
+}
+
+func TestPlate_RestoreStateWrittenBeforeNormalize(t *testing.T) {
+	loyalty := tariff.Loyalty{{Visits: 2, Percent: 10}}
+	backends := map[string]func(db store.Store) restorableUseCase{
+		"slice": func(db store.Store) restorableUseCase {
+			return backend.NewParkingService(backend.WithSimulatedHours(), backend.WithStore(db), backend.WithLoyalty(loyalty))
+		},
+		"btree": func(db store.Store) restorableUseCase {
+			return backend.NewParkingServiceBTree(backend.WithSimulatedHours(), backend.WithStore(db), backend.WithLoyalty(loyalty))
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			// visit keyed by the raw plate, and a plate the current rule reject in the log
+			assert.NoError(t, db.Save(store.Snapshot{
+				LotCapacity: 2,
+				CarList:     make([]*types.Car, 2),
+				Tx:          map[string]int{"B 1234 ABC": 1, "b1234abc": 1},
+			}))
+			assert.NoError(t, db.Append(store.Entry{Seq: 1, Op: "enter", At: time.Now(), Data: json.RawMessage(`{"request":{"police_number":"B/99"}}`)}))
+
+			uc := newBackend(db)
+			assert.NoError(t, uc.Restore())
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, "B/99", statusData.CarList[0].PoliceNumber)
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B/98"})
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B-1234-ABC"})
+			assert.NoError(t, err)
+			exitedCar, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B1234ABC", Hours: 3})
+			assert.NoError(t, err)
+			assert.Equal(t, "loyalty 10% after 2 visit", exitedCar.DiscountReason)
+		})
+	}
+}
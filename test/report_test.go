@@ -0,0 +1,123 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/clock"
+	"github.com/khafidprayoga/parking-app/internal/extra"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport_RevenueAndOccupancyPerPeriod(t *testing.T) {
+	day := time.Date(2024, 6, 3, 0, 0, 0, 0, time.Local)
+	now := clock.NewFake(day)
+	backends := map[string]func() contract.IParkingUseCase{
+		"slice": func() contract.IParkingUseCase { return backend.NewParkingService(backend.WithClock(now)) },
+		"btree": func() contract.IParkingUseCase { return backend.NewParkingServiceBTree(backend.WithClock(now)) },
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			now.Set(day)
+			uc := newBackend()
+			_, err := uc.Report(types.ReportQuery{})
+			assert.ErrorIs(t, err, contract.ErrNotInitialized)
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(2)))
+
+			// first day B1 08:00-10:00 and B2 08:30-11:00 overlap
+			now.Set(day.Add(8 * time.Hour))
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B1"})
+			assert.NoError(t, err)
+			now.Advance(30 * time.Minute)
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B2"})
+			assert.NoError(t, err)
+			now.Set(day.Add(10 * time.Hour))
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B1"})
+			assert.NoError(t, err)
+			now.Set(day.Add(11 * time.Hour))
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B2"})
+			assert.NoError(t, err)
+
+			// second day B3 09:00-12:00 refunded 5, B4 still parked since 13:00
+			now.Set(day.Add(33 * time.Hour))
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B3"})
+			assert.NoError(t, err)
+			now.Set(day.Add(36 * time.Hour))
+			exitedCar, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B3"})
+			assert.NoError(t, err)
+			_, err = uc.Refund(types.RefundDTO{PaymentId: exitedCar.PaymentId, Amount: plain(5)})
+			assert.NoError(t, err)
+			now.Set(day.Add(37 * time.Hour))
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B4"})
+			assert.NoError(t, err)
+			now.Set(day.Add(38 * time.Hour))
+
+			report, err := uc.Report(types.ReportQuery{From: day, To: day.AddDate(0, 0, 2)})
+			assert.NoError(t, err)
+			assert.Equal(t, types.PeriodDaily, report.Period)
+			assert.Equal(t, 2, report.Capacity)
+			assert.Equal(t, []types.ReportRow{
+				{Start: day, End: day.AddDate(0, 0, 1), Revenue: idr(30), Sessions: 2, AverageMinutes: 135, PeakOccupancy: 2, Turnover: 1},
+				{Start: day.AddDate(0, 0, 1), End: day.AddDate(0, 0, 2), Revenue: idr(15), Sessions: 1, AverageMinutes: 180, PeakOccupancy: 1, Turnover: 0.5},
+			}, report.Rows)
+			assert.Equal(t, types.ReportRow{Start: day, End: day.AddDate(0, 0, 2), Revenue: idr(45), Sessions: 3, AverageMinutes: 150, PeakOccupancy: 2, Turnover: 1.5}, report.Total)
+			assert.Equal(t, 150*time.Minute, report.Total.AverageDuration())
+
+			// the first and last hour is clipped to the range
+			report, err = uc.Report(types.ReportQuery{Period: types.PeriodHourly, From: day.Add(8*time.Hour + 15*time.Minute), To: day.Add(11*time.Hour + 30*time.Minute)})
+			assert.NoError(t, err)
+			assert.Len(t, report.Rows, 4)
+			assert.Equal(t, day.Add(8*time.Hour+15*time.Minute), report.Rows[0].Start)
+			assert.Equal(t, day.Add(11*time.Hour+30*time.Minute), report.Rows[3].End)
+			peaks := []int{}
+			sessions := []int{}
+			for _, row := range report.Rows {
+				peaks = append(peaks, row.PeakOccupancy)
+				sessions = append(sessions, row.Sessions)
+			}
+			assert.Equal(t, []int{2, 2, 1, 0}, peaks)
+			assert.Equal(t, []int{0, 0, 1, 1}, sessions)
+
+			// default range run from the first entry until now
+			report, err = uc.Report(types.ReportQuery{Period: types.PeriodMonthly})
+			assert.NoError(t, err)
+			assert.Len(t, report.Rows, 1)
+			assert.Equal(t, day.Add(8*time.Hour), report.Rows[0].Start)
+			assert.Equal(t, now.Now(), report.Rows[0].End)
+			assert.Equal(t, idr(45), report.Rows[0].Revenue)
+			assert.Equal(t, report.Total, report.Rows[0])
+
+			_, err = uc.Report(types.ReportQuery{Period: "weekly"})
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+			_, err = uc.Report(types.ReportQuery{From: day.AddDate(0, 0, 1), To: day})
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+			_, err = uc.Report(types.ReportQuery{Period: types.PeriodHourly, From: day.AddDate(-1, 0, 0)})
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+		})
+	}
+}
+
+func TestReport_ParseArgs(t *testing.T) {
+	query, format, err := extra.ParseReportArgs([]string{"Hourly", "from=2024-06-03", "to=2024-06-04", "format=csv"})
+	assert.NoError(t, err)
+	assert.Equal(t, extra.FormatCSV, format)
+	assert.Equal(t, types.ReportQuery{
+		Period: types.PeriodHourly,
+		From:   time.Date(2024, 6, 3, 0, 0, 0, 0, time.Local),
+		To:     time.Date(2024, 6, 4, 0, 0, 0, 0, time.Local),
+	}, query)
+
+	query, format, err = extra.ParseReportArgs(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, extra.FormatTable, format)
+	assert.Equal(t, types.PeriodDaily, query.GetPeriod())
+
+	for _, invalid := range [][]string{{"format=pdf"}, {"from=yesterday"}, {"plate=B1"}} {
+		_, _, err = extra.ParseReportArgs(invalid)
+		assert.Error(t, err)
+	}
+}
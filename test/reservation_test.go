@@ -0,0 +1,284 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/clock"
+	"github.com/khafidprayoga/parking-app/internal/extra"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservation_HoldUntilArrivalOrNoShow(t *testing.T) {
+	start := time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)
+	now := clock.NewFake(start)
+	backends := map[string]func() contract.IParkingUseCase{
+		"slice": func() contract.IParkingUseCase { return backend.NewParkingService(backend.WithClock(now)) },
+		"btree": func() contract.IParkingUseCase { return backend.NewParkingServiceBTree(backend.WithClock(now)) },
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			now.Set(start)
+			uc := newBackend()
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(3)))
+
+			from := start
+			reservation, err := uc.Reserve(types.ReservationDTO{RequestId: "req-1", PoliceNumber: "b1res", From: from, To: from.Add(2 * time.Hour)})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, reservation.Slot)
+			assert.Equal(t, "B1RES", reservation.PoliceNumber)
+			assert.Equal(t, "req-1", reservation.RequestId)
+			assert.NotEqual(t, "req-1", reservation.Id)
+			assert.Equal(t, from.Add(15*time.Minute), reservation.ExpireAt)
+
+			_, err = uc.Reserve(types.ReservationDTO{PoliceNumber: "B1RES", From: from, To: from.Add(time.Hour)})
+			assert.ErrorIs(t, err, contract.ErrAlreadyReserved)
+
+			// walk-in skip the reserved slot
+			areaId, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B2WALK"})
+			assert.NoError(t, err)
+			assert.Equal(t, 2, areaId)
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, []types.ClassOccupancy{{Class: types.ClassCar, Capacity: 3, Occupied: 1, Reserved: 1}}, statusData.Occupancy)
+
+			// the reserved car arrive within the grace period and take its slot
+			now.Advance(10 * time.Minute)
+			areaId, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B1RES"})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, areaId)
+
+			// specific slot, then no-show after the grace period
+			_, err = uc.Reserve(types.ReservationDTO{PoliceNumber: "B3RES", Slot: 2, From: now.Now(), To: now.Now().Add(time.Hour)})
+			assert.ErrorIs(t, err, contract.ErrSlotOccupied)
+			_, err = uc.Reserve(types.ReservationDTO{PoliceNumber: "B3RES", Slot: 4, From: now.Now(), To: now.Now().Add(time.Hour)})
+			assert.ErrorIs(t, err, contract.ErrSlotNotFound)
+			reservation, err = uc.Reserve(types.ReservationDTO{PoliceNumber: "B3RES", Slot: 3, From: now.Now(), To: now.Now().Add(time.Hour)})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, reservation.Slot)
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B4WALK"})
+			assert.ErrorIs(t, err, contract.ErrLotFull)
+			assert.ErrorIs(t, uc.DisableSlot(3, "cleaning", false), contract.ErrSlotOccupied)
+
+			now.Advance(16 * time.Minute)
+			statusData, err = uc.Status()
+			assert.NoError(t, err)
+			assert.Nil(t, statusData.Slots[2].Reserved)
+
+			areaId, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B4WALK"})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, areaId)
+
+			assert.ErrorIs(t, uc.CancelReservation("B3RES"), contract.ErrNoReservation)
+		})
+	}
+}
+
+func TestReservation_HoldOnlyWithinWindow(t *testing.T) {
+	start := time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)
+	now := clock.NewFake(start)
+	backends := map[string]func() contract.IParkingUseCase{
+		"slice": func() contract.IParkingUseCase { return backend.NewParkingService(backend.WithClock(now)) },
+		"btree": func() contract.IParkingUseCase { return backend.NewParkingServiceBTree(backend.WithClock(now)) },
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			now.Set(start)
+			uc := newBackend()
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(2)))
+
+			from := start.Add(2 * time.Hour)
+			reservation, err := uc.Reserve(types.ReservationDTO{PoliceNumber: "B1RES", From: from, To: from.Add(30 * time.Minute)})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, reservation.Slot)
+
+			// the window has not started, walk-in car still take the slot
+			areaId, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B2WALK"})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, areaId)
+
+			// at the window start the reservation move to the free slot and hold it
+			now.Set(from)
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B3WALK"})
+			assert.ErrorIs(t, err, contract.ErrLotFull)
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Nil(t, statusData.Slots[0].Reserved)
+			assert.Equal(t, 2, statusData.Slots[1].Reserved.Slot)
+
+			now.Advance(5 * time.Minute)
+			areaId, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B1RES"})
+			assert.NoError(t, err)
+			assert.Equal(t, 2, areaId)
+
+			// window shorter than the grace period is released at its end
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B2WALK"})
+			assert.NoError(t, err)
+			_, err = uc.Reserve(types.ReservationDTO{PoliceNumber: "B4RES", From: now.Now(), To: now.Now().Add(5 * time.Minute)})
+			assert.NoError(t, err)
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B3WALK"})
+			assert.ErrorIs(t, err, contract.ErrLotFull)
+
+			now.Advance(5 * time.Minute)
+			areaId, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B3WALK"})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, areaId)
+		})
+	}
+}
+
+func TestReservation_ExpiredDoNotBlockMaintenance(t *testing.T) {
+	start := time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)
+	now := clock.NewFake(start)
+	backends := map[string]func(db store.Store) restorableUseCase{
+		"slice": func(db store.Store) restorableUseCase {
+			return backend.NewParkingService(backend.WithClock(now), backend.WithStore(db))
+		},
+		"btree": func(db store.Store) restorableUseCase {
+			return backend.NewParkingServiceBTree(backend.WithClock(now), backend.WithStore(db))
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			now.Set(start)
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			before := newBackend(db)
+			assert.NoError(t, before.Restore())
+			assert.NoError(t, before.OpenParkingArea(types.UniformLayout(3)))
+			_, err = before.Reserve(types.ReservationDTO{PoliceNumber: "B1RES", Slot: 2, From: start, To: start.Add(time.Hour)})
+			assert.NoError(t, err)
+			_, err = before.Reserve(types.ReservationDTO{PoliceNumber: "B2RES", Slot: 3, From: start, To: start.Add(time.Hour)})
+			assert.NoError(t, err)
+
+			assert.ErrorIs(t, before.DisableSlot(2, "cleaning", false), contract.ErrSlotOccupied)
+			assert.ErrorIs(t, before.ResizeParkingArea(types.UniformLayout(1), false), contract.ErrSlotOccupied)
+
+			// both car did not arrive within the grace period, the slot is free again
+			now.Advance(16 * time.Minute)
+			assert.NoError(t, before.DisableSlot(2, "cleaning", false))
+			assert.NoError(t, before.ResizeParkingArea(types.UniformLayout(1), false))
+
+			after := newBackend(db)
+			assert.NoError(t, after.Restore())
+
+			statusData, err := after.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, 1, statusData.LotParkingCapacity)
+			assert.Nil(t, statusData.Slots[0].Reserved)
+		})
+	}
+}
+
+func TestReservation_CancelAndValidate(t *testing.T) {
+	start := time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)
+	now := clock.NewFake(start)
+
+	for name, uc := range map[string]contract.IParkingUseCase{
+		"slice": backend.NewParkingService(backend.WithClock(now)),
+		"btree": backend.NewParkingServiceBTree(backend.WithClock(now)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := uc.Reserve(types.ReservationDTO{PoliceNumber: "B1RES", From: start, To: start.Add(time.Hour)})
+			assert.ErrorIs(t, err, contract.ErrNotInitialized)
+			assert.NoError(t, uc.OpenParkingArea(types.LotLayout{{Class: types.ClassCar, Count: 1}, {Class: types.ClassMoto, Count: 1}}))
+
+			for _, invalid := range []types.ReservationDTO{
+				{From: start, To: start.Add(time.Hour)},
+				{PoliceNumber: "B1RES", From: start, To: start},
+				{PoliceNumber: "B1RES", From: start.Add(-time.Hour), To: start},
+				{PoliceNumber: "B1RES", Class: "truck", From: start, To: start.Add(time.Hour)},
+				{PoliceNumber: "B1RES", Slot: 2, From: start, To: start.Add(time.Hour)},
+			} {
+				_, err = uc.Reserve(invalid)
+				assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+			}
+
+			reservation, err := uc.Reserve(types.ReservationDTO{PoliceNumber: "B1RES", From: start, To: start.Add(time.Hour)})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, reservation.Slot)
+
+			_, err = uc.Reserve(types.ReservationDTO{PoliceNumber: "B2RES", From: start, To: start.Add(time.Hour)})
+			assert.ErrorIs(t, err, contract.ErrLotFull)
+
+			// held slot can not be removed by resize
+			assert.ErrorIs(t, uc.ResizeParkingArea(types.LotLayout{{Class: types.ClassMoto, Count: 2}}, true), contract.ErrSlotOccupied)
+
+			assert.NoError(t, uc.CancelReservation("b1res"))
+			areaId, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B2WALK"})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, areaId)
+
+			// forced disable drop the reservation, the car does not park on disabled slot
+			_, err = uc.Reserve(types.ReservationDTO{PoliceNumber: "B3RES", Class: types.ClassMoto, From: start, To: start.Add(time.Hour)})
+			assert.NoError(t, err)
+			assert.NoError(t, uc.DisableSlot(2, "cleaning", true))
+			assert.ErrorIs(t, uc.CancelReservation("B3RES"), contract.ErrNoReservation)
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B3RES", Class: types.ClassMoto})
+			assert.ErrorIs(t, err, contract.ErrLotFull)
+		})
+	}
+}
+
+func TestReservation_RestoreReservedSlot(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			from := time.Now()
+			before := newBackend(db)
+			assert.NoError(t, before.Restore())
+			assert.NoError(t, before.OpenParkingArea(types.UniformLayout(3)))
+			_, err = before.Reserve(types.ReservationDTO{PoliceNumber: "B1RES", From: from, To: from.Add(time.Hour)})
+			assert.NoError(t, err)
+			assert.NoError(t, before.Compact())
+			_, err = before.Reserve(types.ReservationDTO{PoliceNumber: "B2RES", Slot: 3, From: from, To: from.Add(time.Hour)})
+			assert.NoError(t, err)
+			_, err = before.Reserve(types.ReservationDTO{PoliceNumber: "B3RES", From: from, To: from.Add(time.Hour)})
+			assert.NoError(t, err)
+			assert.NoError(t, before.CancelReservation("B3RES"))
+
+			after := newBackend(db)
+			assert.NoError(t, after.Restore())
+
+			statusData, err := after.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, "B1RES", statusData.Slots[0].Reserved.PoliceNumber)
+			assert.Nil(t, statusData.Slots[1].Reserved)
+			assert.Equal(t, "B2RES", statusData.Slots[2].Reserved.PoliceNumber)
+
+			areaId, err := after.EnterArea(types.CarDTO{PoliceNumber: "B4WALK"})
+			assert.NoError(t, err)
+			assert.Equal(t, 2, areaId)
+
+			areaId, err = after.EnterArea(types.CarDTO{PoliceNumber: "B2RES"})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, areaId)
+		})
+	}
+}
+
+func TestReservation_ParseReserveArgs(t *testing.T) {
+	reservation, err := extra.ParseReserveArgs([]string{"B", "1234", "ABC", "2024-05-01T09:00", "2024-05-01T12:00", "slot=3", "class=ev"})
+	assert.NoError(t, err)
+	assert.Equal(t, "B 1234 ABC", reservation.PoliceNumber)
+	assert.Equal(t, 3, reservation.Slot)
+	assert.Equal(t, "ev", reservation.Class)
+	assert.Equal(t, 3*time.Hour, reservation.To.Sub(reservation.From))
+
+	_, err = extra.ParseReserveArgs([]string{"B1234ABC", "2024-05-01T09:00"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,131 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResize_GrowAndShrink(t *testing.T) {
+	for name, newBackend := range allBackends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+			assert.ErrorIs(t, uc.ResizeParkingArea(types.UniformLayout(2), false), contract.ErrNotInitialized)
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(2)))
+
+			for _, plate := range []string{"B1", "B2"} {
+				_, err := uc.EnterArea(types.CarDTO{PoliceNumber: plate})
+				assert.NoError(t, err)
+			}
+
+			// grow is instant
+			assert.NoError(t, uc.ResizeParkingArea(types.UniformLayout(4), false))
+			areaId, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B3"})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, areaId)
+
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B2", Hours: 1})
+			assert.NoError(t, err)
+
+			// B3 is on slot 3
+			err = uc.ResizeParkingArea(types.UniformLayout(2), false)
+			assert.ErrorIs(t, err, contract.ErrSlotOccupied)
+			assert.Contains(t, err.Error(), "B3")
+
+			assert.NoError(t, uc.ResizeParkingArea(types.UniformLayout(3), false))
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, 3, statusData.LotParkingCapacity)
+			assert.Len(t, statusData.CarList, 3)
+
+			// occupied slot can not change into class its car does not fit
+			err = uc.ResizeParkingArea(types.LotLayout{{Class: types.ClassMoto, Count: 3}}, true)
+			assert.ErrorIs(t, err, contract.ErrSlotOccupied)
+		})
+	}
+}
+
+func TestResize_DrainOccupiedSlot(t *testing.T) {
+	for name, newBackend := range allBackends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(3)))
+
+			for _, plate := range []string{"B1", "B2", "B3"} {
+				_, err := uc.EnterArea(types.CarDTO{PoliceNumber: plate})
+				assert.NoError(t, err)
+			}
+			_, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B1", Hours: 1})
+			assert.NoError(t, err)
+
+			assert.NoError(t, uc.ResizeParkingArea(types.UniformLayout(1), true))
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, 1, statusData.LotParkingCapacity)
+			assert.Len(t, statusData.CarList, 3)
+			assert.True(t, statusData.Slots[1].Draining)
+			assert.Equal(t, []types.ClassOccupancy{{Class: types.ClassCar, Capacity: 1}}, statusData.Occupancy)
+
+			// draining slot never take new car
+			areaId, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B4"})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, areaId)
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B5"})
+			assert.ErrorIs(t, err, contract.ErrLotFull)
+
+			// slot is dropped once the tail is empty
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B2", Hours: 1})
+			assert.NoError(t, err)
+			statusData, _ = uc.Status()
+			assert.Len(t, statusData.CarList, 3)
+
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B3", Hours: 1})
+			assert.NoError(t, err)
+			statusData, _ = uc.Status()
+			assert.Len(t, statusData.CarList, 1)
+			assert.Len(t, statusData.Slots, 1)
+		})
+	}
+}
+
+func TestResize_RestoreDrainingSlot(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			before := newBackend(db)
+			assert.NoError(t, before.Restore())
+			assert.NoError(t, before.OpenParkingArea(types.UniformLayout(3)))
+			for _, plate := range []string{"B1", "B2", "B3"} {
+				_, err = before.EnterArea(types.CarDTO{PoliceNumber: plate})
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, before.ResizeParkingArea(types.UniformLayout(1), true))
+			assert.NoError(t, before.Compact())
+			_, err = before.LeaveArea(types.CarDTO{PoliceNumber: "B3", Hours: 1})
+			assert.NoError(t, err)
+
+			after := newBackend(db)
+			assert.NoError(t, after.Restore())
+
+			statusData, err := after.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, 1, statusData.LotParkingCapacity)
+			assert.Len(t, statusData.CarList, 2)
+			assert.True(t, statusData.Slots[1].Draining)
+
+			_, err = after.LeaveArea(types.CarDTO{PoliceNumber: "B1", Hours: 1})
+			assert.NoError(t, err)
+			areaId, err := after.EnterArea(types.CarDTO{PoliceNumber: "B4"})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, areaId)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/server"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_StatusCarryTypedData(t *testing.T) {
+	srv := server.CreateAppServer(backend.NewParkingService())
+
+	_, _, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdCreateStore, Data: "2"})
+	assert.NoError(t, err)
+	_, _, err = srv.HandleIncomingMsg(types.Socket{Command: types.CmdPark, Data: types.CarDTO{PoliceNumber: "B1234ABC"}})
+	assert.NoError(t, err)
+
+	msg, data, err := srv.HandleIncomingMsg(types.Socket{Command: types.CmdStatus})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, msg)
+
+	// what the client see after the response travel over the wire
+	wireBytes, err := json.Marshal(types.SocketServerResponse{Status: types.SocketCallSuccess, Message: msg, Data: data})
+	assert.NoError(t, err)
+
+	res := types.SocketServerResponse{}
+	assert.NoError(t, json.Unmarshal(wireBytes, &res))
+
+	status := types.AppStatus{}
+	assert.NoError(t, res.Bind(&status))
+	assert.Equal(t, 2, status.LotParkingCapacity)
+	assert.Equal(t, "B1234ABC", status.CarList[0].PoliceNumber)
+	assert.Nil(t, status.CarList[1])
+}
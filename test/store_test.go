@@ -0,0 +1,198 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/tariff"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type restorableUseCase interface {
+	contract.IParkingUseCase
+	Restore() error
+	Compact() error
+}
+
+var persistentBackends = map[string]func(db store.Store) restorableUseCase{
+	"slice": func(db store.Store) restorableUseCase {
+		return backend.NewParkingService(backend.WithSimulatedHours(), backend.WithStore(db))
+	},
+	"btree": func(db store.Store) restorableUseCase {
+		return backend.NewParkingServiceBTree(backend.WithSimulatedHours(), backend.WithStore(db))
+	},
+}
+
+func TestStore_RestoreAfterRestart(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			// first server lifetime
+			before := newBackend(db)
+			assert.NoError(t, before.Restore())
+			assert.NoError(t, before.OpenParkingArea(types.UniformLayout(3)))
+
+			_, err = before.EnterArea(types.CarDTO{RequestId: "req-1", PoliceNumber: "B1234ABC"})
+			assert.NoError(t, err)
+			_, err = before.EnterArea(types.CarDTO{RequestId: "req-2", PoliceNumber: "B5678DEF"})
+			assert.NoError(t, err)
+			_, err = before.LeaveArea(types.CarDTO{PoliceNumber: "B1234ABC", Hours: 4})
+			assert.NoError(t, err)
+
+			// second server lifetime on the same data
+			after := newBackend(db)
+			assert.NoError(t, after.Restore())
+
+			statusData, err := after.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, 3, statusData.LotParkingCapacity)
+			assert.Equal(t, idr(30), statusData.Revenue)
+			assert.Equal(t, 1, statusData.TxCount)
+			assert.Nil(t, statusData.CarList[0])
+			assert.Equal(t, "B5678DEF", statusData.CarList[1].PoliceNumber)
+
+			// restored state keep allocating the nearest free slot and reject second open
+			areaId, err := after.EnterArea(types.CarDTO{RequestId: "req-3", PoliceNumber: "B9999XYZ"})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, areaId)
+
+			_, err = after.EnterArea(types.CarDTO{RequestId: "req-4", PoliceNumber: "B5678DEF"})
+			assert.Error(t, err)
+			assert.Error(t, after.OpenParkingArea(types.UniformLayout(5)))
+		})
+	}
+}
+
+func TestStore_CompactAndReplay(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			db, err := store.NewFileStore(dir)
+			assert.NoError(t, err)
+
+			before := newBackend(db)
+			assert.NoError(t, before.Restore())
+			assert.NoError(t, before.OpenParkingArea(types.UniformLayout(2)))
+			_, err = before.EnterArea(types.CarDTO{RequestId: "req-1", PoliceNumber: "B1234ABC"})
+			assert.NoError(t, err)
+
+			// folded into snapshot, the log only keep what comes after
+			assert.NoError(t, before.Compact())
+			_, err = before.LeaveArea(types.CarDTO{PoliceNumber: "B1234ABC", Hours: 1})
+			assert.NoError(t, err)
+			_, err = before.EnterArea(types.CarDTO{RequestId: "req-2", PoliceNumber: "B5678DEF"})
+			assert.NoError(t, err)
+			assert.NoError(t, db.Close())
+
+			// simulate crash in the middle of appending next entry
+			wal, err := os.OpenFile(filepath.Join(dir, "wal.log"), os.O_WRONLY|os.O_APPEND, 0o644)
+			assert.NoError(t, err)
+			_, err = wal.WriteString(`0badc0de {"seq":4,"op":"ent`)
+			assert.NoError(t, err)
+			assert.NoError(t, wal.Close())
+
+			reopened, err := store.NewFileStore(dir)
+			assert.NoError(t, err)
+			defer reopened.Close()
+
+			after := newBackend(reopened)
+			assert.NoError(t, after.Restore())
+
+			statusData, err := after.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, idr(10), statusData.Revenue)
+			assert.Equal(t, 1, statusData.TxCount)
+			assert.Equal(t, "B5678DEF", statusData.CarList[0].PoliceNumber)
+			assert.Nil(t, statusData.CarList[1])
+
+			// torn tail is dropped, new entries append cleanly after it
+			_, err = after.EnterArea(types.CarDTO{RequestId: "req-3", PoliceNumber: "B9999XYZ"})
+			assert.NoError(t, err)
+			_, entries, err := reopened.Load()
+			assert.NoError(t, err)
+			assert.Len(t, entries, 3)
+		})
+	}
+}
+
+func TestStore_ReplayKeepChargedPrice(t *testing.T) {
+	backends := map[string]func(db store.Store, opts ...backend.Option) restorableUseCase{
+		"slice": func(db store.Store, opts ...backend.Option) restorableUseCase {
+			return backend.NewParkingService(append(opts, backend.WithSimulatedHours(), backend.WithStore(db))...)
+		},
+		"btree": func(db store.Store, opts ...backend.Option) restorableUseCase {
+			return backend.NewParkingServiceBTree(append(opts, backend.WithSimulatedHours(), backend.WithStore(db))...)
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			before := newBackend(db)
+			assert.NoError(t, before.Restore())
+			assert.NoError(t, before.OpenParkingArea(types.UniformLayout(1)))
+			_, err = before.EnterArea(types.CarDTO{RequestId: "req-1", PoliceNumber: "B1234ABC"})
+			assert.NoError(t, err)
+			_, err = before.LeaveArea(types.CarDTO{PoliceNumber: "B1234ABC", Hours: 4})
+			assert.NoError(t, err)
+
+			// tariff changed between the two runs
+			rule, err := tariff.NewRuleSet(tariff.Config{HourlyRate: plain(100)})
+			assert.NoError(t, err)
+			after := newBackend(db, backend.WithTariff(rule))
+			assert.NoError(t, after.Restore())
+
+			statusData, err := after.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, idr(30), statusData.Revenue)
+		})
+	}
+}
+
+func TestStore_RestoreSnapshotBeforeOpen(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			// lot closed by capacity zero still carry its ledger, session and visit
+			assert.NoError(t, db.Save(store.Snapshot{
+				Tx:       map[string]int{"B1234ABC": 2},
+				Sessions: []types.Session{{PoliceNumber: "B1234ABC", Cost: idr(10)}},
+				Ledger: types.Ledger{{
+					Id:     "p1",
+					Kind:   types.EntryPayment,
+					Debit:  types.PaymentCash,
+					Credit: types.AccountRevenue,
+					Amount: idr(10),
+				}},
+				Currency: types.DefaultCurrency,
+			}))
+
+			uc := newBackend(db)
+			assert.NoError(t, uc.Restore())
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(1)))
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, idr(10), statusData.Revenue)
+			assert.Equal(t, 2, statusData.TxCount)
+
+			sessions, err := uc.History(types.HistoryQuery{})
+			assert.NoError(t, err)
+			assert.Len(t, sessions, 1)
+		})
+	}
+}
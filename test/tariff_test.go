@@ -0,0 +1,225 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/backend"
+	"github.com/khafidprayoga/parking-app/internal/clock"
+	"github.com/khafidprayoga/parking-app/internal/tariff"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTariff_RuleSet(t *testing.T) {
+	// friday
+	entry := time.Date(2024, 5, 31, 20, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		cfg      tariff.Config
+		duration time.Duration
+		expected int64
+	}{
+		{
+			name:     "Base fee cover the first 2 hours",
+			cfg:      tariff.Config{BaseFee: plain(10), BaseHours: 2, HourlyRate: plain(10)},
+			duration: 2 * time.Hour,
+			expected: 10,
+		},
+		{
+			name:     "Every started extra hour is charged",
+			cfg:      tariff.Config{BaseFee: plain(10), BaseHours: 2, HourlyRate: plain(10)},
+			duration: 3*time.Hour + time.Minute,
+			expected: 30,
+		},
+		{
+			name:     "First 15 minutes free",
+			cfg:      tariff.Config{BaseFee: plain(10), BaseHours: 2, HourlyRate: plain(10), FreeMinutes: 15},
+			duration: 15 * time.Minute,
+			expected: 0,
+		},
+		{
+			name:     "Free period exceeded charge base fee",
+			cfg:      tariff.Config{BaseFee: plain(10), BaseHours: 2, HourlyRate: plain(10), FreeMinutes: 15},
+			duration: 16 * time.Minute,
+			expected: 10,
+		},
+		{
+			name:     "Daily cap on every 24 hours",
+			cfg:      tariff.Config{BaseFee: plain(10), BaseHours: 2, HourlyRate: plain(10), DailyCap: plain(50)},
+			duration: 30 * time.Hour,
+			expected: 100,
+		},
+		{
+			name: "Night flat fee charged once per night",
+			cfg: tariff.Config{HourlyRate: plain(5), Timezone: "UTC",
+				Night: &tariff.NightConfig{Start: "22:00", End: "06:00", FlatFee: plain(20)}},
+			duration: 12 * time.Hour,
+			expected: 5 + 5 + 20 + 5 + 5,
+		},
+		{
+			name: "Round up to 15 minutes unit",
+			cfg: tariff.Config{HourlyRate: plain(10),
+				Rounding: &tariff.RoundingConfig{UnitMinutes: 15, Mode: tariff.RoundUp}},
+			duration: 59*time.Minute + 59*time.Second,
+			expected: 10,
+		},
+		{
+			name: "Round down to 15 minutes unit",
+			cfg: tariff.Config{HourlyRate: plain(10),
+				Rounding: &tariff.RoundingConfig{UnitMinutes: 15, Mode: tariff.RoundDown}},
+			duration: time.Hour + 14*time.Minute,
+			expected: 10,
+		},
+		{
+			name: "Round nearest to 30 minutes unit",
+			cfg: tariff.Config{HourlyRate: plain(10),
+				Rounding: &tariff.RoundingConfig{UnitMinutes: 30, Mode: tariff.RoundNearest}},
+			duration: 2*time.Hour + 16*time.Minute,
+			expected: 30,
+		},
+		{
+			name:     "Weekend hourly rate from saturday",
+			cfg:      tariff.Config{HourlyRate: plain(10), WeekendHourlyRate: plain(20), Timezone: "UTC"},
+			duration: 6 * time.Hour,
+			expected: 4*10 + 2*20,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := tariff.NewRuleSet(tc.cfg)
+			assert.NoError(t, err)
+			assert.Equal(t, plain(tc.expected), rule.Cost(entry, entry.Add(tc.duration)))
+		})
+	}
+}
+
+func TestTariff_InvalidConfig(t *testing.T) {
+	_, err := tariff.NewRuleSet(tariff.Config{HourlyRate: plain(-1)})
+	assert.Error(t, err)
+
+	_, err = tariff.NewRuleSet(tariff.Config{Night: &tariff.NightConfig{Start: "25:00", End: "06:00"}})
+	assert.Error(t, err)
+
+	// base fee never charged without the hour it cover
+	_, err = tariff.NewRuleSet(tariff.Config{BaseFee: plain(5)})
+	assert.Error(t, err)
+
+	_, err = tariff.NewRuleSet(tariff.Config{Timezone: "Mars/Olympus"})
+	assert.Error(t, err)
+
+	_, err = tariff.NewRuleSet(tariff.Config{Rounding: &tariff.RoundingConfig{UnitMinutes: 15, Mode: "sideways"}})
+	assert.Error(t, err)
+}
+
+func TestTariff_BackendDelegatePricing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tariff.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"base_fee": 5, "base_hours": 1, "hourly_rate": 3, "daily_cap": 20}`), 0o644))
+
+	rule, err := tariff.LoadFile(path)
+	assert.NoError(t, err)
+
+	backends := map[string]func() contract.IParkingUseCase{
+		"slice": func() contract.IParkingUseCase {
+			return backend.NewParkingService(backend.WithSimulatedHours(), backend.WithTariff(rule))
+		},
+		"btree": func() contract.IParkingUseCase {
+			return backend.NewParkingServiceBTree(backend.WithSimulatedHours(), backend.WithTariff(rule))
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(2)))
+
+			_, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B1234ABC"})
+			assert.NoError(t, err)
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B5678DEF"})
+			assert.NoError(t, err)
+
+			exitedCar, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B1234ABC", Hours: 3})
+			assert.NoError(t, err)
+			assert.Equal(t, idr(11), exitedCar.Cost)
+
+			exitedCar, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B5678DEF", Hours: 10})
+			assert.NoError(t, err)
+			assert.Equal(t, idr(20), exitedCar.Cost)
+		})
+	}
+}
+
+func TestTariff_BillRealElapsedTime(t *testing.T) {
+	backends := map[string]func(c clock.Clock) contract.IParkingUseCase{
+		"slice": func(c clock.Clock) contract.IParkingUseCase {
+			return backend.NewParkingService(backend.WithSimulatedHours(), backend.WithClock(c))
+		},
+		"btree": func(c clock.Clock) contract.IParkingUseCase {
+			return backend.NewParkingServiceBTree(backend.WithSimulatedHours(), backend.WithClock(c))
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			now := clock.NewFake(time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC))
+			uc := newBackend(now)
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(2)))
+
+			_, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B1234ABC"})
+			assert.NoError(t, err)
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B5678DEF"})
+			assert.NoError(t, err)
+
+			now.Advance(3*time.Hour + 30*time.Minute)
+
+			// no hours, billed from parking time until now
+			exitedCar, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B1234ABC"})
+			assert.NoError(t, err)
+			assert.Equal(t, idr(30), exitedCar.Cost)
+			assert.Equal(t, now.Now(), *exitedCar.ExitAt)
+
+			// hours override only the billed duration for simulation, the exit time is still now
+			exitedCar, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B5678DEF", Hours: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, idr(10), exitedCar.Cost)
+			assert.Equal(t, now.Now(), *exitedCar.ExitAt)
+		})
+	}
+}
+
+func TestTariff_HoursOverrideNeedSimulation(t *testing.T) {
+	backends := map[string]func(c clock.Clock) contract.IParkingUseCase{
+		"slice": func(c clock.Clock) contract.IParkingUseCase {
+			return backend.NewParkingService(backend.WithClock(c))
+		},
+		"btree": func(c clock.Clock) contract.IParkingUseCase {
+			return backend.NewParkingServiceBTree(backend.WithClock(c))
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			now := clock.NewFake(time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC))
+			uc := newBackend(now)
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(1)))
+			_, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B1234ABC"})
+			assert.NoError(t, err)
+
+			now.Advance(5 * time.Hour)
+
+			// a live server without simulation never bill the client supplied hours
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B1234ABC", Hours: 1})
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+
+			exitedCar, err := uc.LeaveArea(types.CarDTO{PoliceNumber: "B1234ABC"})
+			assert.NoError(t, err)
+			assert.Equal(t, idr(40), exitedCar.Cost)
+			assert.Equal(t, now.Now(), *exitedCar.ExitAt)
+		})
+	}
+}
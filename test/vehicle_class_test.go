@@ -0,0 +1,173 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/store"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehicleClass_ParseLotLayout(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		expected    types.LotLayout
+		expectError bool
+	}{
+		{
+			name:     "Plain capacity is car slot",
+			input:    "6",
+			expected: types.LotLayout{{Class: types.ClassCar, Count: 6}},
+		},
+		{
+			name:  "Typed slot group keep the order",
+			input: "car=20 MOTO=10 ev=4",
+			expected: types.LotLayout{
+				{Class: types.ClassCar, Count: 20},
+				{Class: types.ClassMoto, Count: 10},
+				{Class: types.ClassEV, Count: 4},
+			},
+		},
+		{
+			name:        "Group without count",
+			input:       "car=20 moto",
+			expectError: true,
+		},
+		{
+			name:        "Count is not number",
+			input:       "car=many",
+			expectError: true,
+		},
+		{
+			name:        "Empty layout",
+			input:       " ",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			layout, err := types.ParseLotLayout(tc.input)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, layout)
+		})
+	}
+}
+
+func TestVehicleClass_NearestCompatibleSlot(t *testing.T) {
+	// 1 moto, 2-3 car, 4 ev, 5 van
+	layout := types.LotLayout{
+		{Class: types.ClassMoto, Count: 1},
+		{Class: types.ClassCar, Count: 2},
+		{Class: types.ClassEV, Count: 1},
+		{Class: types.ClassVan, Count: 1},
+	}
+
+	for name, newBackend := range allBackends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+			assert.NoError(t, uc.OpenParkingArea(layout))
+
+			steps := []struct {
+				plate        string
+				class        string
+				expectedArea int
+				expectedErr  error
+			}{
+				{"B1MOTO", types.ClassMoto, 1, nil},
+				{"B2MOTO", types.ClassMoto, 2, nil},
+				{"B1VAN", types.ClassVan, 5, nil},
+				{"B2VAN", types.ClassVan, 0, contract.ErrLotFull},
+				{"B1CAR", "", 3, nil},
+				{"B1EV", types.ClassEV, 4, nil},
+				{"B2CAR", types.ClassCar, 0, contract.ErrLotFull},
+				{"B1TRUCK", "truck", 0, contract.ErrInvalidRequest},
+			}
+
+			for _, step := range steps {
+				areaId, err := uc.EnterArea(types.CarDTO{PoliceNumber: step.plate, Class: step.class})
+				if step.expectedErr != nil {
+					assert.ErrorIs(t, err, step.expectedErr, step.plate)
+					continue
+				}
+				assert.NoError(t, err, step.plate)
+				assert.Equal(t, step.expectedArea, areaId, step.plate)
+			}
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, 5, statusData.LotParkingCapacity)
+			assert.Equal(t, types.ClassMoto, statusData.CarList[1].Class)
+			assert.Equal(t, types.ClassCar, statusData.Slots[1].Class)
+			assert.Equal(t, []types.ClassOccupancy{
+				{Class: types.ClassMoto, Capacity: 1, Occupied: 1},
+				{Class: types.ClassCar, Capacity: 2, Occupied: 2},
+				{Class: types.ClassEV, Capacity: 1, Occupied: 1},
+				{Class: types.ClassVan, Capacity: 1, Occupied: 1},
+			}, statusData.Occupancy)
+
+			// motorcycle leave the car slot, the car take it back
+			_, err = uc.LeaveArea(types.CarDTO{PoliceNumber: "B2MOTO", Hours: 1})
+			assert.NoError(t, err)
+
+			areaId, err := uc.EnterArea(types.CarDTO{PoliceNumber: "B2CAR", Class: types.ClassCar})
+			assert.NoError(t, err)
+			assert.Equal(t, 2, areaId)
+		})
+	}
+}
+
+func TestVehicleClass_InvalidLayout(t *testing.T) {
+	for name, newBackend := range allBackends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+
+			err := uc.OpenParkingArea(types.LotLayout{{Class: "truck", Count: 2}})
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+
+			err = uc.OpenParkingArea(types.LotLayout{{Class: types.ClassCar, Count: 2}, {Class: types.ClassEV, Count: 0}})
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+
+			assert.NoError(t, uc.OpenParkingArea(types.LotLayout{{Class: types.ClassCar, Count: 2}}))
+		})
+	}
+}
+
+func TestVehicleClass_RestoreTypedSlot(t *testing.T) {
+	for name, newBackend := range persistentBackends {
+		t.Run(name, func(t *testing.T) {
+			db, err := store.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			defer db.Close()
+
+			before := newBackend(db)
+			assert.NoError(t, before.Restore())
+			assert.NoError(t, before.OpenParkingArea(types.LotLayout{
+				{Class: types.ClassEV, Count: 1},
+				{Class: types.ClassMoto, Count: 1},
+			}))
+			assert.NoError(t, before.Compact())
+
+			_, err = before.EnterArea(types.CarDTO{PoliceNumber: "B1MOTO", Class: types.ClassMoto})
+			assert.NoError(t, err)
+
+			after := newBackend(db)
+			assert.NoError(t, after.Restore())
+
+			// ev slot is still kept for ev after restart
+			_, err = after.EnterArea(types.CarDTO{PoliceNumber: "B1CAR"})
+			assert.ErrorIs(t, err, contract.ErrLotFull)
+
+			areaId, err := after.EnterArea(types.CarDTO{PoliceNumber: "B1EV", Class: types.ClassEV})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, areaId)
+		})
+	}
+}
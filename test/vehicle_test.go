@@ -0,0 +1,102 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/khafidprayoga/parking-app/contract"
+	"github.com/khafidprayoga/parking-app/internal/extra"
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehicle_AttributeAndColorSearch(t *testing.T) {
+	for name, newBackend := range allBackends {
+		t.Run(name, func(t *testing.T) {
+			uc := newBackend()
+			_, err := uc.SearchCars(types.CarFilter{})
+			assert.ErrorIs(t, err, contract.ErrNotInitialized)
+			assert.NoError(t, uc.OpenParkingArea(types.UniformLayout(4)))
+
+			for _, car := range []types.CarDTO{
+				{PoliceNumber: "B1", Color: " white ", Make: "Toyota", Model: "Avanza", Photo: "anpr/0001.jpg"},
+				{PoliceNumber: "B2", Color: "BLACK", Make: "Honda"},
+				{PoliceNumber: "B3"},
+				{PoliceNumber: "B4", Color: "White", Make: "Honda", Class: types.ClassMoto},
+			} {
+				_, err = uc.EnterArea(car)
+				assert.NoError(t, err)
+			}
+
+			car, err := uc.FindCar("B1")
+			assert.NoError(t, err)
+			assert.Equal(t, "White", car.Color)
+			assert.Equal(t, "Toyota", car.Make)
+			assert.Equal(t, "Avanza", car.Model)
+			assert.Equal(t, "anpr/0001.jpg", car.Photo)
+
+			statusData, err := uc.Status()
+			assert.NoError(t, err)
+			assert.Equal(t, "Black", statusData.CarList[1].Color)
+			assert.Empty(t, statusData.CarList[2].Color)
+
+			cars, err := uc.SearchCars(types.CarFilter{Color: "WHITE"})
+			assert.NoError(t, err)
+			assert.Len(t, cars, 2)
+			assert.Equal(t, "B1", cars[0].PoliceNumber)
+			assert.Equal(t, "B4", cars[1].PoliceNumber)
+
+			cars, err = uc.SearchCars(types.CarFilter{Color: "white", Make: "honda", Class: types.ClassMoto})
+			assert.NoError(t, err)
+			assert.Len(t, cars, 1)
+			assert.Equal(t, "B4", cars[0].PoliceNumber)
+
+			cars, err = uc.SearchCars(types.CarFilter{Color: "red"})
+			assert.NoError(t, err)
+			assert.Empty(t, cars)
+
+			cars, err = uc.SearchCars(types.CarFilter{})
+			assert.NoError(t, err)
+			assert.Len(t, cars, 4)
+
+			_, err = uc.EnterArea(types.CarDTO{PoliceNumber: "B5", Make: strings.Repeat("x", 33)})
+			assert.ErrorIs(t, err, contract.ErrInvalidRequest)
+		})
+	}
+}
+
+func TestVehicle_ColorCapitalized(t *testing.T) {
+	for input, expected := range map[string]string{
+		" WHITE ": "White",
+		"éclair":  "Éclair",
+		"ÜMBER":   "Ümber",
+		"":        "",
+	} {
+		t.Run(input, func(t *testing.T) {
+			color := types.CarDTO{Color: input}.GetColor()
+			assert.Equal(t, expected, color)
+			assert.True(t, utf8.ValidString(color))
+		})
+	}
+}
+
+func TestVehicle_ParseArgs(t *testing.T) {
+	car, err := extra.ParseParkArgs([]string{"B", "1234", "color=white", "make=Toyota", "model=Avanza", "photo=anpr/1.jpg", "class=ev"})
+	assert.NoError(t, err)
+	assert.Equal(t, types.CarDTO{
+		PoliceNumber: "B 1234",
+		Class:        "ev",
+		Color:        "white",
+		Make:         "Toyota",
+		Model:        "Avanza",
+		Photo:        "anpr/1.jpg",
+	}, car)
+
+	filter, err := extra.ParseSearchArgs([]string{"white", "make=Toyota"})
+	assert.NoError(t, err)
+	assert.Equal(t, types.CarFilter{Color: "white", Make: "Toyota"}, filter)
+
+	_, err = extra.ParseSearchArgs([]string{"plate=B1"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/khafidprayoga/parking-app/internal/types"
+	"github.com/khafidprayoga/parking-app/internal/wire"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWire_FrameRoundTrip(t *testing.T) {
+	// big status response that used to be truncated by single 2048 bytes read
+	status := types.AppStatus{LotParkingCapacity: 500}
+	for i := 0; i < 500; i++ {
+		status.CarList = append(status.CarList, &types.Car{
+			AreaNumber:   i + 1,
+			PoliceNumber: fmt.Sprintf("KA-01-HH-%04d", i),
+		})
+	}
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, wire.WriteFrame(&buf, status))
+	assert.NoError(t, wire.WriteFrame(&buf, types.Socket{Command: types.CmdStatus}))
+
+	decoded := types.AppStatus{}
+	assert.NoError(t, wire.ReadFrame(&buf, &decoded))
+	assert.Len(t, decoded.CarList, 500)
+	assert.Equal(t, "KA-01-HH-0499", decoded.CarList[499].PoliceNumber)
+
+	// next frame start exactly after the previous one
+	req := types.Socket{}
+	assert.NoError(t, wire.ReadFrame(&buf, &req))
+	assert.Equal(t, types.CmdStatus, req.Command)
+
+	// clean close between frames
+	assert.Equal(t, io.EOF, wire.ReadFrame(&buf, &req))
+}
+
+func TestWire_FrameTooLarge(t *testing.T) {
+	t.Run("Reject on write", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		err := wire.WriteFrame(&buf, bytes.Repeat([]byte("a"), wire.MaxFrameSize))
+		assert.ErrorIs(t, err, wire.ErrFrameTooLarge)
+		assert.Equal(t, 0, buf.Len())
+	})
+
+	t.Run("Reject on read", func(t *testing.T) {
+		header := make([]byte, 4)
+		binary.BigEndian.PutUint32(header, wire.MaxFrameSize+1)
+
+		err := wire.ReadFrame(bytes.NewReader(header), &types.Socket{})
+		assert.ErrorIs(t, err, wire.ErrFrameTooLarge)
+	})
+
+	t.Run("Truncated payload", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		assert.NoError(t, wire.WriteFrame(&buf, types.Socket{Command: types.CmdPark}))
+
+		err := wire.ReadFrame(bytes.NewReader(buf.Bytes()[:buf.Len()-2]), &types.Socket{})
+		assert.Error(t, err)
+		assert.NotEqual(t, io.EOF, err)
+	})
+}